@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
 
+	"studytracker/internal/config"
+	"studytracker/internal/platform/logging"
 	"studytracker/internal/router"
 )
 
@@ -16,27 +24,83 @@ func main() {
 	if err := godotenv.Load("../.env"); err == nil {
 		log.Println("loaded ../.env")
 	}
-	log.Printf("GOOGLE_CLIENT_ID=%s", os.Getenv("GOOGLE_CLIENT_ID"))
-	log.Printf("GOOGLE_REDIRECT_URL=%s", os.Getenv("GOOGLE_REDIRECT_URL"))
-	log.Printf("GOOGLE_CLIENT_SECRET length=%d", len(os.Getenv("GOOGLE_CLIENT_SECRET")))
 
-	addr := ":" + getEnv("PORT", "8080")
-	dsn := getEnv("DATABASE_URL", "file:data/studytracker.db?_pragma=foreign_keys(ON)")
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "config" {
+		if err := runConfigCommand(args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	app, err := router.New(dsn)
+	cfg, err := config.Load(args)
 	if err != nil {
-		log.Fatalf("failed to bootstrap router: %v", err)
+		log.Fatal(err)
 	}
 
-	log.Printf("study-tracker API listening on %s", addr)
-	if err := app.Listen(addr); err != nil {
+	logger, err := logging.New(os.Stderr, logging.Options{
+		Level:        cfg.Logging.Level,
+		Format:       cfg.Logging.Format,
+		RedactEmails: cfg.Logging.RedactEmails,
+	})
+	if err != nil {
 		log.Fatal(err)
 	}
+	slog.SetDefault(logger)
+	logger.Info("configuration loaded", "googleSignIn", cfg.GoogleEnabled(), "metrics", cfg.Metrics.Enabled)
+
+	app, err := router.New(cfg, logger)
+	if err != nil {
+		logger.Error("failed to bootstrap router", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := serve(ctx, app, cfg, logger); err != nil {
+		logger.Error("server stopped with error", "error", err)
+		os.Exit(1)
+	}
 }
 
-func getEnv(key, fallback string) string {
-	if value, ok := os.LookupEnv(key); ok && value != "" {
-		return value
+// serve runs the HTTP server until ctx is cancelled, then stops accepting new
+// connections and waits up to the configured timeout for in-flight requests.
+// Fiber's shutdown hooks stop background jobs and close the database afterwards.
+func serve(ctx context.Context, app *fiber.App, cfg config.Config, logger *slog.Logger) error {
+	addr := cfg.Addr()
+	listenErr := make(chan error, 1)
+	go func() {
+		logger.Info("study-tracker API listening", "addr", addr)
+		listenErr <- app.Listen(addr)
+	}()
+
+	select {
+	case err := <-listenErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutdown signal received, draining requests", "timeout", cfg.Server.ShutdownTimeout)
+	if err := app.ShutdownWithTimeout(cfg.Server.ShutdownTimeout); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := <-listenErr; err != nil {
+		return err
+	}
+	logger.Info("server stopped")
+	return nil
+}
+
+// runConfigCommand implements `server config print [flags]`, which shows the
+// effective configuration with secrets redacted.
+func runConfigCommand(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("usage: server config print [flags]")
+	}
+	cfg, err := config.Load(args[1:])
+	if err != nil {
+		return err
 	}
-	return fallback
+	return cfg.Print(os.Stdout)
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"studytracker/internal/config"
+)
+
+// TestServeDrainsInFlightRequestOnSignal sends a real SIGINT or SIGTERM
+// while a request is being handled and checks that the request completes
+// before serve returns.
+func TestServeDrainsInFlightRequestOnSignal(t *testing.T) {
+	for _, sig := range []syscall.Signal{syscall.SIGINT, syscall.SIGTERM} {
+		t.Run(sig.String(), func(t *testing.T) {
+			started := make(chan struct{})
+			app := fiber.New(fiber.Config{DisableStartupMessage: true})
+			app.Get("/slow", func(c *fiber.Ctx) error {
+				close(started)
+				time.Sleep(300 * time.Millisecond)
+				return c.SendString("done")
+			})
+
+			cfg := config.Default()
+			cfg.Server.Port = freePort(t)
+			cfg.Server.ShutdownTimeout = 5 * time.Second
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			// Registered like main does, so the signal cancels ctx instead of
+			// killing the test binary.
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			served := make(chan error, 1)
+			go func() { served <- serve(ctx, app, cfg, logger) }()
+			url := fmt.Sprintf("http://127.0.0.1:%d/slow", cfg.Server.Port)
+			waitForListener(t, cfg.Server.Port)
+
+			type result struct {
+				body string
+				err  error
+			}
+			responses := make(chan result, 1)
+			go func() {
+				resp, err := http.Get(url)
+				if err != nil {
+					responses <- result{err: err}
+					return
+				}
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				responses <- result{body: string(body), err: err}
+			}()
+
+			select {
+			case <-started:
+			case <-time.After(5 * time.Second):
+				t.Fatal("request never reached the handler")
+			}
+			if err := syscall.Kill(os.Getpid(), sig); err != nil {
+				t.Fatalf("send %v: %v", sig, err)
+			}
+
+			var res result
+			select {
+			case res = <-responses:
+			case <-time.After(5 * time.Second):
+				t.Fatal("in-flight request did not finish")
+			}
+			if res.err != nil || res.body != "done" {
+				t.Fatalf("in-flight request got body %q, err %v; want it to finish", res.body, res.err)
+			}
+
+			select {
+			case err := <-served:
+				if err != nil {
+					t.Fatalf("serve returned %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("serve did not return after the signal")
+			}
+			if _, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", cfg.Server.Port), 200*time.Millisecond); err == nil {
+				t.Fatal("server still accepts connections after shutdown")
+			}
+		})
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func waitForListener(t *testing.T, port int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 100*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("server did not start listening on port %d", port)
+}
@@ -0,0 +1,25 @@
+package auth
+
+import "errors"
+
+var (
+	ErrInvalidRegistration = errors.New("invalid email or password")
+	ErrEmailTaken          = errors.New("email already registered")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrFederatedAccount    = errors.New("account uses federated login")
+)
+
+// RegistrationError names the registration field that failed validation. It
+// matches ErrInvalidRegistration.
+type RegistrationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *RegistrationError) Error() string {
+	return e.Field + " " + e.Reason
+}
+
+func (e *RegistrationError) Unwrap() error {
+	return ErrInvalidRegistration
+}
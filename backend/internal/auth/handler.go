@@ -1,12 +1,15 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/oauth2"
+
+	"studytracker/internal/platform/apierror"
 )
 
 // Handler exposes auth-related HTTP endpoints.
@@ -47,12 +50,12 @@ type credentials struct {
 func (h *Handler) register(c *fiber.Ctx) error {
 	var body credentials
 	if err := c.BodyParser(&body); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+		return apierror.InvalidPayload()
 	}
 
-	result, err := h.service.Register(body.Email, body.Password)
+	result, err := h.service.Register(c.UserContext(), body.Email, body.Password)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return mapError(err)
 	}
 	h.setAuthCookie(c, result.Session)
 	return c.Status(fiber.StatusCreated).JSON(result.User)
@@ -61,12 +64,12 @@ func (h *Handler) register(c *fiber.Ctx) error {
 func (h *Handler) login(c *fiber.Ctx) error {
 	var body credentials
 	if err := c.BodyParser(&body); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+		return apierror.InvalidPayload()
 	}
 
-	result, err := h.service.Login(body.Email, body.Password)
+	result, err := h.service.Login(c.UserContext(), body.Email, body.Password)
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		return mapError(err)
 	}
 	h.setAuthCookie(c, result.Session)
 	return c.JSON(result.User)
@@ -96,12 +99,12 @@ func (h *Handler) me(c *fiber.Ctx) error {
 func (h *Handler) googleLogin(c *fiber.Ctx) error {
 	cfg := h.service.OAuthConfig()
 	if cfg == nil {
-		return fiber.NewError(fiber.StatusBadRequest, "google login not configured")
+		return apierror.New(fiber.StatusBadRequest, "google_not_configured", "google login not configured")
 	}
 
 	state, err := h.service.GenerateOAuthState()
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "failed to start oauth flow")
+		return apierror.Wrap(err, fiber.StatusInternalServerError, apierror.CodeInternal, "failed to start oauth flow")
 	}
 	h.setStateCookie(c, state)
 
@@ -118,24 +121,24 @@ func (h *Handler) googleLogin(c *fiber.Ctx) error {
 func (h *Handler) googleCallback(c *fiber.Ctx) error {
 	cfg := h.service.OAuthConfig()
 	if cfg == nil {
-		return fiber.NewError(fiber.StatusBadRequest, "google login not configured")
+		return apierror.New(fiber.StatusBadRequest, "google_not_configured", "google login not configured")
 	}
 
 	stateFromCookie := c.Cookies("oauth_state")
 	state := c.Query("state")
 	if stateFromCookie == "" || state != stateFromCookie {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid oauth state")
+		return apierror.New(fiber.StatusBadRequest, "invalid_oauth_state", "invalid oauth state")
 	}
 
 	code := c.Query("code")
 	if code == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "missing code")
+		return apierror.Validation("missing_oauth_code", "missing code", apierror.Field("code", "is required"))
 	}
 
 	redirectURL := h.resolveRedirectURL(c)
 	result, err := h.service.HandleGoogleCallback(c.Context(), code, redirectURL)
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		return apierror.Wrap(err, fiber.StatusUnauthorized, "oauth_failed", "google sign-in failed")
 	}
 
 	h.setAuthCookie(c, result.Session)
@@ -149,7 +152,28 @@ func (h *Handler) googleCallback(c *fiber.Ctx) error {
 }
 
 func (h *Handler) googleDisabled(c *fiber.Ctx) error {
-	return fiber.NewError(fiber.StatusNotImplemented, "google login disabled")
+	return apierror.New(fiber.StatusNotImplemented, "google_login_disabled", "google login disabled")
+}
+
+// errorMappings translates auth errors into API error codes.
+var errorMappings = []apierror.Mapping{
+	{Target: ErrInvalidRegistration, Status: fiber.StatusBadRequest, Code: "invalid_registration"},
+	{Target: ErrEmailTaken, Status: fiber.StatusConflict, Code: "email_taken",
+		Details: []apierror.FieldError{apierror.Field("email", "is already registered")}},
+	{Target: ErrInvalidCredentials, Status: fiber.StatusUnauthorized, Code: "invalid_credentials"},
+	{Target: ErrFederatedAccount, Status: fiber.StatusUnauthorized, Code: "federated_account"},
+}
+
+func mapError(err error) error {
+	// Registration errors name the field that failed, so they cannot use a
+	// static mapping.
+	var registration *RegistrationError
+	if errors.As(err, &registration) {
+		return &apierror.Error{Status: fiber.StatusBadRequest, Code: "invalid_registration",
+			Message: ErrInvalidRegistration.Error(), Err: err,
+			Details: []apierror.FieldError{apierror.Field(registration.Field, registration.Reason)}}
+	}
+	return apierror.Map(err, errorMappings...)
 }
 
 func (h *Handler) setAuthCookie(c *fiber.Ctx, session Session) {
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"studytracker/internal/platform/apierror"
+)
+
+func TestMapErrorNamesTheRegistrationField(t *testing.T) {
+	tests := []struct {
+		err  error
+		want []apierror.FieldError
+	}{
+		{
+			err:  &RegistrationError{Field: "email", Reason: "is required"},
+			want: []apierror.FieldError{apierror.Field("email", "is required")},
+		},
+		{
+			err:  &RegistrationError{Field: "password", Reason: "must be at least 8 characters"},
+			want: []apierror.FieldError{apierror.Field("password", "must be at least 8 characters")},
+		},
+	}
+	for _, tt := range tests {
+		var apiErr *apierror.Error
+		if !errors.As(mapError(tt.err), &apiErr) || apiErr.Code != "invalid_registration" {
+			t.Fatalf("mapError(%v) = %v, want invalid_registration", tt.err, apiErr)
+		}
+		if !reflect.DeepEqual(apiErr.Details, tt.want) {
+			t.Fatalf("details = %v, want %v", apiErr.Details, tt.want)
+		}
+		if !errors.Is(tt.err, ErrInvalidRegistration) {
+			t.Fatalf("%v does not match ErrInvalidRegistration", tt.err)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package auth
 
 import (
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,13 +11,15 @@ import (
 type Middleware struct {
 	sessions  SessionStore
 	cookieKey string
+	logger    *slog.Logger
 }
 
 // NewMiddleware constructs an auth middleware.
-func NewMiddleware(store SessionStore) *Middleware {
+func NewMiddleware(store SessionStore, logger *slog.Logger) *Middleware {
 	return &Middleware{
 		sessions:  store,
 		cookieKey: "session_token",
+		logger:    logger,
 	}
 }
 
@@ -25,19 +27,19 @@ func NewMiddleware(store SessionStore) *Middleware {
 func (m *Middleware) RequireAuth(c *fiber.Ctx) error {
 	sessionID := c.Cookies(m.cookieKey)
 	if sessionID == "" {
-		logUnauthorized(c, "missing session cookie")
+		m.logUnauthorized(c, "missing session cookie")
 		return fiber.ErrUnauthorized
 	}
 
 	session, err := m.sessions.Get(sessionID)
 	if err != nil {
-		logUnauthorized(c, "session lookup failed")
+		m.logUnauthorized(c, "session lookup failed")
 		return fiber.ErrUnauthorized
 	}
 
 	if time.Now().After(session.ExpiresAt) {
 		_ = m.sessions.Delete(sessionID)
-		logUnauthorized(c, "session expired")
+		m.logUnauthorized(c, "session expired")
 		return fiber.ErrUnauthorized
 	}
 
@@ -46,6 +48,6 @@ func (m *Middleware) RequireAuth(c *fiber.Ctx) error {
 	return c.Next()
 }
 
-func logUnauthorized(c *fiber.Ctx, reason string) {
-	log.Printf("unauthorized %s %s: %s", c.Method(), c.Path(), reason)
+func (m *Middleware) logUnauthorized(c *fiber.Ctx, reason string) {
+	m.logger.InfoContext(c.UserContext(), "unauthorized request", "method", c.Method(), "path", c.Path(), "reason", reason)
 }
@@ -8,7 +8,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
@@ -21,6 +21,22 @@ import (
 	"studytracker/internal/user"
 )
 
+// minPasswordLength is the shortest password accepted at registration.
+const minPasswordLength = 8
+
+// Recorder receives auth events for instrumentation.
+type Recorder interface {
+	Registered()
+	LoggedIn()
+	LoginFailed()
+}
+
+type nopRecorder struct{}
+
+func (nopRecorder) Registered()  {}
+func (nopRecorder) LoggedIn()    {}
+func (nopRecorder) LoginFailed() {}
+
 // Service coordinates auth flows.
 type Service struct {
 	users       user.Repository
@@ -28,6 +44,8 @@ type Service struct {
 	sessionTTL  time.Duration
 	oauthConfig *oauth2.Config
 	httpClient  *http.Client
+	recorder    Recorder
+	logger      *slog.Logger
 }
 
 // Config contains auth configuration knobs.
@@ -38,16 +56,21 @@ type Config struct {
 	GoogleRedirectURL  string
 }
 
-// NewService constructs an auth service.
-func NewService(repo user.Repository, sessions SessionStore, cfg Config) *Service {
+// NewService constructs an auth service. recorder may be nil.
+func NewService(repo user.Repository, sessions SessionStore, cfg Config, recorder Recorder, logger *slog.Logger) *Service {
 	if cfg.SessionTTL == 0 {
 		cfg.SessionTTL = 24 * time.Hour
 	}
+	if recorder == nil {
+		recorder = nopRecorder{}
+	}
 	service := &Service{
 		users:      repo,
 		sessions:   sessions,
 		sessionTTL: cfg.SessionTTL,
 		httpClient: &http.Client{Timeout: 10 * time.Second},
+		recorder:   recorder,
+		logger:     logger,
 	}
 	if cfg.GoogleClientID != "" && cfg.GoogleClientSecret != "" && cfg.GoogleRedirectURL != "" {
 		service.oauthConfig = &oauth2.Config{
@@ -71,14 +94,17 @@ type AuthResult struct {
 }
 
 // Register creates a new user using email/password credentials.
-func (s *Service) Register(email, password string) (AuthResult, error) {
+func (s *Service) Register(ctx context.Context, email, password string) (AuthResult, error) {
 	email = normalizeEmail(email)
-	if email == "" || len(password) < 8 {
-		return AuthResult{}, errors.New("invalid email or password")
+	if email == "" {
+		return AuthResult{}, &RegistrationError{Field: "email", Reason: "is required"}
+	}
+	if len(password) < minPasswordLength {
+		return AuthResult{}, &RegistrationError{Field: "password", Reason: fmt.Sprintf("must be at least %d characters", minPasswordLength)}
 	}
 
 	if _, err := s.users.GetByEmail(email); err == nil {
-		return AuthResult{}, errors.New("email already registered")
+		return AuthResult{}, ErrEmailTaken
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -103,43 +129,48 @@ func (s *Service) Register(email, password string) (AuthResult, error) {
 	if err != nil {
 		return AuthResult{}, err
 	}
-	log.Printf("created user id=%s email=%s provider=%s", created.ID, created.Email, created.Provider)
+	s.logger.InfoContext(ctx, "user registered", "userId", created.ID, "email", created.Email, "provider", created.Provider)
 
 	session, err := s.sessions.Create(created.ID, s.sessionTTL)
 	if err != nil {
 		return AuthResult{}, err
 	}
+	s.recorder.Registered()
 
 	return AuthResult{User: created, Session: session}, nil
 }
 
 // Login authenticates a user via email/password.
-func (s *Service) Login(email, password string) (AuthResult, error) {
+func (s *Service) Login(ctx context.Context, email, password string) (AuthResult, error) {
 	email = normalizeEmail(email)
 	if email == "" || password == "" {
-		return AuthResult{}, errors.New("invalid email or password")
+		return AuthResult{}, ErrInvalidCredentials
 	}
 
 	u, err := s.users.GetByEmail(email)
 	if err != nil {
-		log.Printf("login failed lookup email=%s: %v", email, err)
-		return AuthResult{}, errors.New("invalid credentials")
+		s.logger.InfoContext(ctx, "login failed: unknown account", "email", email, "error", err)
+		s.recorder.LoginFailed()
+		return AuthResult{}, ErrInvalidCredentials
 	}
 
 	if u.Provider != "local" {
-		log.Printf("login failed email=%s uses provider=%s", email, u.Provider)
-		return AuthResult{}, errors.New("account uses federated login")
+		s.logger.InfoContext(ctx, "login failed: federated account", "email", email, "provider", u.Provider)
+		s.recorder.LoginFailed()
+		return AuthResult{}, ErrFederatedAccount
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
-		log.Printf("login failed bcrypt mismatch email=%s", email)
-		return AuthResult{}, errors.New("invalid credentials")
+		s.logger.InfoContext(ctx, "login failed: password mismatch", "email", email)
+		s.recorder.LoginFailed()
+		return AuthResult{}, ErrInvalidCredentials
 	}
 
 	session, err := s.sessions.Create(u.ID, s.sessionTTL)
 	if err != nil {
 		return AuthResult{}, err
 	}
+	s.recorder.LoggedIn()
 
 	return AuthResult{User: u, Session: session}, nil
 }
@@ -23,6 +23,7 @@ type SessionStore interface {
 	Create(userID string, ttl time.Duration) (Session, error)
 	Get(id string) (Session, error)
 	Delete(id string) error
+	DeleteExpired(now time.Time) (int64, error)
 }
 
 // SQLSessionStore implements SessionStore using SQLite.
@@ -89,6 +90,15 @@ func (s *SQLSessionStore) Delete(id string) error {
 	return err
 }
 
+func (s *SQLSessionStore) DeleteExpired(now time.Time) (int64, error) {
+	const query = `DELETE FROM sessions WHERE expires_at < ?;`
+	res, err := s.db.ExecContext(context.Background(), s.rebind(query), now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (s *SQLSessionStore) rebind(query string) string {
 	return database.Rebind(query, s.useDollar)
 }
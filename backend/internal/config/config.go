@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config is the fully resolved configuration for the server.
+type Config struct {
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	API      APIConfig      `yaml:"api" toml:"api"`
+	Health   HealthConfig   `yaml:"health" toml:"health"`
+	Metrics  MetricsConfig  `yaml:"metrics" toml:"metrics"`
+	Logging  LoggingConfig  `yaml:"logging" toml:"logging"`
+}
+
+// ServerConfig controls the HTTP listener and static frontend.
+type ServerConfig struct {
+	Port            int           `yaml:"port" toml:"port"`
+	FrontendURL     string        `yaml:"frontendUrl" toml:"frontendUrl"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout"`
+	// LegacyAPISunset is advertised in the Sunset header of the unversioned /api alias.
+	LegacyAPISunset time.Time `yaml:"legacyApiSunset" toml:"legacyApiSunset"`
+}
+
+// DatabaseConfig selects the SQL database to connect to.
+type DatabaseConfig struct {
+	URL string `yaml:"url" toml:"url"`
+}
+
+// AuthConfig contains session and OAuth settings.
+type AuthConfig struct {
+	SessionTTL time.Duration `yaml:"sessionTtl" toml:"sessionTtl"`
+	Google     GoogleConfig  `yaml:"google" toml:"google"`
+}
+
+// GoogleConfig holds the optional Google Sign-In credentials.
+type GoogleConfig struct {
+	ClientID     string `yaml:"clientId" toml:"clientId"`
+	ClientSecret string `yaml:"clientSecret" toml:"clientSecret"`
+	RedirectURL  string `yaml:"redirectUrl" toml:"redirectUrl"`
+}
+
+// APIConfig tunes request handling for the JSON API.
+type APIConfig struct {
+	// IdempotencyTTL is how long a stored Idempotency-Key response can be replayed.
+	IdempotencyTTL time.Duration `yaml:"idempotencyTtl" toml:"idempotencyTtl"`
+	// BatchMaxSize caps the operations accepted by one batch request.
+	BatchMaxSize int `yaml:"batchMaxSize" toml:"batchMaxSize"`
+	// TrashRetention is how long deleted sessions and subjects can be restored.
+	TrashRetention time.Duration `yaml:"trashRetention" toml:"trashRetention"`
+}
+
+// HealthConfig tunes the readiness probe.
+type HealthConfig struct {
+	CheckTimeout time.Duration `yaml:"checkTimeout" toml:"checkTimeout"`
+	CacheTTL     time.Duration `yaml:"cacheTtl" toml:"cacheTtl"`
+}
+
+// MetricsConfig controls the Prometheus endpoint. Enabling it requires a
+// token unless AllowLoopback explicitly opts into unauthenticated loopback
+// access, which is only safe when no proxy runs on the same host.
+type MetricsConfig struct {
+	Enabled       bool   `yaml:"enabled" toml:"enabled"`
+	Token         string `yaml:"token" toml:"token"`
+	AllowLoopback bool   `yaml:"allowLoopback" toml:"allowLoopback"`
+}
+
+// LoggingConfig controls the structured logger.
+type LoggingConfig struct {
+	Level        string `yaml:"level" toml:"level"`
+	Format       string `yaml:"format" toml:"format"`
+	RedactEmails bool   `yaml:"redactEmails" toml:"redactEmails"`
+}
+
+// Default returns the configuration used for local development.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:            8080,
+			ShutdownTimeout: 15 * time.Second,
+			LegacyAPISunset: time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC),
+		},
+		Database: DatabaseConfig{
+			URL: "file:data/studytracker.db?_pragma=foreign_keys(ON)",
+		},
+		Auth: AuthConfig{
+			SessionTTL: 24 * time.Hour,
+		},
+		API: APIConfig{
+			IdempotencyTTL: 24 * time.Hour,
+			BatchMaxSize:   100,
+			TrashRetention: 30 * 24 * time.Hour,
+		},
+		Logging: LoggingConfig{
+			Level:        "info",
+			Format:       "text",
+			RedactEmails: true,
+		},
+		Health: HealthConfig{
+			CheckTimeout: 2 * time.Second,
+			CacheTTL:     5 * time.Second,
+		},
+	}
+}
+
+// Addr returns the listen address for the HTTP server.
+func (c Config) Addr() string {
+	return fmt.Sprintf(":%d", c.Server.Port)
+}
+
+// Validate reports every invalid setting at once so misconfiguration is fixed in one pass.
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port: must be between 1 and 65535, got %d", c.Server.Port))
+	}
+	if c.Server.FrontendURL != "" {
+		if err := validateFrontendURL(c.Server.FrontendURL); err != nil {
+			errs = append(errs, fmt.Errorf("server.frontendUrl: %w", err))
+		}
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("server.shutdownTimeout: must be positive, got %s", c.Server.ShutdownTimeout))
+	}
+	if strings.TrimSpace(c.Database.URL) == "" {
+		errs = append(errs, errors.New("database.url: is required"))
+	}
+	if c.Auth.SessionTTL <= 0 {
+		errs = append(errs, fmt.Errorf("auth.sessionTtl: must be positive, got %s", c.Auth.SessionTTL))
+	}
+	if c.API.IdempotencyTTL <= 0 {
+		errs = append(errs, fmt.Errorf("api.idempotencyTtl: must be positive, got %s", c.API.IdempotencyTTL))
+	}
+	if c.API.BatchMaxSize < 1 {
+		errs = append(errs, fmt.Errorf("api.batchMaxSize: must be at least 1, got %d", c.API.BatchMaxSize))
+	}
+	if c.API.TrashRetention <= 0 {
+		errs = append(errs, fmt.Errorf("api.trashRetention: must be positive, got %s", c.API.TrashRetention))
+	}
+
+	if c.Health.CheckTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("health.checkTimeout: must be positive, got %s", c.Health.CheckTimeout))
+	}
+	if c.Health.CacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("health.cacheTtl: must not be negative, got %s", c.Health.CacheTTL))
+	}
+
+	if c.Metrics.Enabled && c.Metrics.Token == "" && !c.Metrics.AllowLoopback {
+		errs = append(errs, errors.New("metrics.token: is required when metrics are enabled, unless metrics.allowLoopback is set"))
+	}
+
+	switch strings.ToLower(c.Logging.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("logging.level: must be debug, info, warn or error, got %q", c.Logging.Level))
+	}
+	switch strings.ToLower(c.Logging.Format) {
+	case "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("logging.format: must be text or json, got %q", c.Logging.Format))
+	}
+
+	google := c.Auth.Google
+	set := 0
+	for _, value := range []string{google.ClientID, google.ClientSecret, google.RedirectURL} {
+		if value != "" {
+			set++
+		}
+	}
+	if set > 0 && set < 3 {
+		errs = append(errs, errors.New("auth.google: clientId, clientSecret and redirectUrl must be set together"))
+	}
+	if google.RedirectURL != "" {
+		if err := validateAbsoluteURL(google.RedirectURL); err != nil {
+			errs = append(errs, fmt.Errorf("auth.google.redirectUrl: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// GoogleEnabled reports whether Google Sign-In credentials are configured.
+func (c Config) GoogleEnabled() bool {
+	g := c.Auth.Google
+	return g.ClientID != "" && g.ClientSecret != "" && g.RedirectURL != ""
+}
+
+// validateFrontendURL accepts a path on this server, such as "/", as well as
+// an absolute URL. Protocol-relative "//host" values are refused because they
+// would redirect to another host.
+func validateFrontendURL(raw string) error {
+	if strings.HasPrefix(raw, "/") && !strings.HasPrefix(raw, "//") {
+		return nil
+	}
+	if err := validateAbsoluteURL(raw); err != nil {
+		return fmt.Errorf("must be a path starting with / or an http or https URL, got %q", raw)
+	}
+	return nil
+}
+
+func validateAbsoluteURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("must be an http or https URL, got %q", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("must include a host, got %q", raw)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*Config)
+		wantErr string
+	}{
+		{name: "defaults", change: func(*Config) {}},
+		{name: "frontend root path", change: func(c *Config) { c.Server.FrontendURL = "/" }},
+		{name: "frontend relative path", change: func(c *Config) { c.Server.FrontendURL = "/app/" }},
+		{name: "frontend absolute URL", change: func(c *Config) { c.Server.FrontendURL = "https://study.example.com" }},
+		{
+			name:    "frontend protocol-relative URL",
+			change:  func(c *Config) { c.Server.FrontendURL = "//evil.example.com" },
+			wantErr: "server.frontendUrl",
+		},
+		{
+			name:    "frontend without scheme",
+			change:  func(c *Config) { c.Server.FrontendURL = "study.example.com" },
+			wantErr: "server.frontendUrl",
+		},
+		{name: "port out of range", change: func(c *Config) { c.Server.Port = 70000 }, wantErr: "server.port"},
+		{name: "zero shutdown timeout", change: func(c *Config) { c.Server.ShutdownTimeout = 0 }, wantErr: "server.shutdownTimeout"},
+		{name: "empty database URL", change: func(c *Config) { c.Database.URL = " " }, wantErr: "database.url"},
+		{name: "unknown log level", change: func(c *Config) { c.Logging.Level = "trace" }, wantErr: "logging.level"},
+		{
+			name:    "partial Google credentials",
+			change:  func(c *Config) { c.Auth.Google.ClientID = "id" },
+			wantErr: "auth.google",
+		},
+		{
+			name:    "metrics without token",
+			change:  func(c *Config) { c.Metrics.Enabled = true },
+			wantErr: "metrics.token",
+		},
+		{
+			name:   "metrics with token",
+			change: func(c *Config) { c.Metrics.Enabled, c.Metrics.Token = true, "secret" },
+		},
+		{
+			name:   "metrics with loopback opt-in",
+			change: func(c *Config) { c.Metrics.Enabled, c.Metrics.AllowLoopback = true, true },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.change(&cfg)
+			err := cfg.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Validate() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("Validate() = %v, want an error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryError(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Port = 0
+	cfg.Auth.SessionTTL = 0
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, want errors")
+	}
+	for _, want := range []string{"server.port", "auth.sessionTtl"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %v, missing %q", err, want)
+		}
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	t.Setenv("PORT", "9000")
+	t.Setenv("SESSION_TTL", "2h")
+	t.Setenv("FRONTEND_URL", "/")
+
+	cfg, err := Load([]string{"-port", "9100"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Port != 9100 {
+		t.Errorf("port = %d, want the flag's 9100", cfg.Server.Port)
+	}
+	if cfg.Auth.SessionTTL != 2*time.Hour {
+		t.Errorf("session TTL = %s, want the environment's 2h", cfg.Auth.SessionTTL)
+	}
+	if cfg.Server.FrontendURL != "/" {
+		t.Errorf("frontend URL = %q, want /", cfg.Server.FrontendURL)
+	}
+}
+
+func TestLoadRejectsInvalidEnvironment(t *testing.T) {
+	t.Setenv("SESSION_TTL", "soon")
+	if _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "SESSION_TTL") {
+		t.Fatalf("Load() = %v, want a SESSION_TTL error", err)
+	}
+}
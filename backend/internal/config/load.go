@@ -0,0 +1,232 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Load resolves configuration from defaults, an optional YAML/TOML file,
+// environment variables and command-line flags, in increasing precedence.
+// The file path comes from -config or CONFIG_FILE.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("studytracker", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var (
+		configPath  = fs.String("config", "", "path to a YAML or TOML config file")
+		port        = fs.Int("port", 0, "HTTP listen port")
+		databaseURL = fs.String("database-url", "", "database DSN")
+		frontendURL = fs.String("frontend-url", "", "URL to redirect to after OAuth login")
+		sessionTTL  = fs.Duration("session-ttl", 0, "login session lifetime")
+		shutdown    = fs.Duration("shutdown-timeout", 0, "how long to drain in-flight requests on shutdown")
+		metrics     = fs.Bool("metrics", false, "expose Prometheus metrics at /metrics")
+		logLevel    = fs.String("log-level", "", "minimum log level: debug, info, warn or error")
+		logFormat   = fs.String("log-format", "", "log output format: text or json")
+	)
+	if err := fs.Parse(args); err != nil {
+		return Config{}, fmt.Errorf("parse flags: %w", err)
+	}
+
+	path := *configPath
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			cfg.Server.Port = *port
+		case "database-url":
+			cfg.Database.URL = *databaseURL
+		case "frontend-url":
+			cfg.Server.FrontendURL = *frontendURL
+		case "session-ttl":
+			cfg.Auth.SessionTTL = *sessionTTL
+		case "shutdown-timeout":
+			cfg.Server.ShutdownTimeout = *shutdown
+		case "metrics":
+			cfg.Metrics.Enabled = *metrics
+		case "log-level":
+			cfg.Logging.Level = *logLevel
+		case "log-format":
+			cfg.Logging.Format = *logFormat
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("parse config file %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("parse config file %s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported extension %q (want .yaml, .yml or .toml)", path, ext)
+	}
+	return nil
+}
+
+// applyEnv overlays environment variables. Values that are present but
+// malformed are rejected instead of silently replaced by defaults.
+func applyEnv(cfg *Config) error {
+	var errs []error
+
+	if value, ok := lookupEnv("PORT"); ok {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("PORT: invalid integer %q", value))
+		}
+		cfg.Server.Port = port
+	}
+	if value, ok := lookupEnv("SHUTDOWN_TIMEOUT"); ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("SHUTDOWN_TIMEOUT: invalid duration %q", value))
+		}
+		cfg.Server.ShutdownTimeout = timeout
+	}
+	if value, ok := lookupEnv("LEGACY_API_SUNSET"); ok {
+		sunset, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("LEGACY_API_SUNSET: invalid date %q (want YYYY-MM-DD)", value))
+		}
+		cfg.Server.LegacyAPISunset = sunset
+	}
+	if value, ok := lookupEnv("DATABASE_URL"); ok {
+		cfg.Database.URL = value
+	}
+	if value, ok := lookupEnv("FRONTEND_URL"); ok {
+		cfg.Server.FrontendURL = value
+	}
+	if value, ok := lookupEnv("SESSION_TTL"); ok {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("SESSION_TTL: invalid duration %q", value))
+		}
+		cfg.Auth.SessionTTL = ttl
+	}
+	if value, ok := lookupEnv("IDEMPOTENCY_TTL"); ok {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("IDEMPOTENCY_TTL: invalid duration %q", value))
+		}
+		cfg.API.IdempotencyTTL = ttl
+	}
+	if value, ok := lookupEnv("BATCH_MAX_SIZE"); ok {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("BATCH_MAX_SIZE: invalid integer %q", value))
+		}
+		cfg.API.BatchMaxSize = size
+	}
+	if value, ok := lookupEnv("TRASH_RETENTION"); ok {
+		retention, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("TRASH_RETENTION: invalid duration %q", value))
+		}
+		cfg.API.TrashRetention = retention
+	}
+	if value, ok := lookupEnv("HEALTH_CHECK_TIMEOUT"); ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("HEALTH_CHECK_TIMEOUT: invalid duration %q", value))
+		}
+		cfg.Health.CheckTimeout = timeout
+	}
+	if value, ok := lookupEnv("HEALTH_CACHE_TTL"); ok {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("HEALTH_CACHE_TTL: invalid duration %q", value))
+		}
+		cfg.Health.CacheTTL = ttl
+	}
+	if value, ok := lookupEnv("METRICS_ENABLED"); ok {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("METRICS_ENABLED: invalid boolean %q", value))
+		}
+		cfg.Metrics.Enabled = enabled
+	}
+	if value, ok := lookupEnv("METRICS_TOKEN"); ok {
+		cfg.Metrics.Token = value
+	}
+	if value, ok := lookupEnv("METRICS_ALLOW_LOOPBACK"); ok {
+		allow, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("METRICS_ALLOW_LOOPBACK: invalid boolean %q", value))
+		}
+		cfg.Metrics.AllowLoopback = allow
+	}
+	if value, ok := lookupEnv("LOG_LEVEL"); ok {
+		cfg.Logging.Level = value
+	}
+	if value, ok := lookupEnv("LOG_FORMAT"); ok {
+		cfg.Logging.Format = value
+	}
+	if value, ok := lookupEnv("LOG_REDACT_EMAILS"); ok {
+		redact, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("LOG_REDACT_EMAILS: invalid boolean %q", value))
+		}
+		cfg.Logging.RedactEmails = redact
+	}
+	if value, ok := lookupEnv("GOOGLE_CLIENT_ID"); ok {
+		cfg.Auth.Google.ClientID = value
+	}
+	if value, ok := lookupEnv("GOOGLE_CLIENT_SECRET"); ok {
+		cfg.Auth.Google.ClientSecret = value
+	}
+	if value, ok := lookupEnv("GOOGLE_REDIRECT_URL"); ok {
+		cfg.Auth.Google.RedirectURL = value
+	}
+
+	return errors.Join(errs...)
+}
+
+func lookupEnv(key string) (string, bool) {
+	// Empty values are treated as unset, matching the previous getEnv helpers.
+	value, ok := os.LookupEnv(key)
+	value = strings.TrimSpace(value)
+	return value, ok && value != ""
+}
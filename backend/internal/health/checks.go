@@ -0,0 +1,31 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"studytracker/internal/platform/database"
+)
+
+// DatabasePing verifies the database accepts connections.
+func DatabasePing(db *sql.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// Migrations verifies every embedded migration is recorded in schema_migrations.
+func Migrations(db *sql.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		pending, err := database.PendingMigrations(ctx, db)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("pending migrations: %s", strings.Join(pending, ", "))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,38 @@
+package health
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler exposes liveness and readiness probes.
+type Handler struct {
+	checker *Checker
+}
+
+// NewHandler creates a health handler bound to a checker.
+func NewHandler(checker *Checker) *Handler {
+	return &Handler{checker: checker}
+}
+
+// RegisterRoutes mounts the probe endpoints. /health is kept as an alias of
+// /health/live for existing deploy targets.
+func (h *Handler) RegisterRoutes(router fiber.Router) {
+	router.Get("/health", h.live)
+	router.Get("/health/live", h.live)
+	router.Get("/health/ready", h.ready)
+}
+
+// live only reports that the process is serving requests.
+func (h *Handler) live(c *fiber.Ctx) error {
+	return c.SendString("ok")
+}
+
+func (h *Handler) ready(c *fiber.Ctx) error {
+	report := h.checker.Run(c.UserContext())
+	status := fiber.StatusOK
+	if report.Status != StatusOK {
+		status = fiber.StatusServiceUnavailable
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).JSON(report)
+}
@@ -0,0 +1,174 @@
+// Package idempotency lets clients retry create requests safely by sending
+// an Idempotency-Key header.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+
+	"studytracker/internal/auth"
+	"studytracker/internal/platform/apierror"
+)
+
+const (
+	// Header carries the client-chosen key.
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set on responses served from a stored record.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+var (
+	// ErrKeyInUse means the original request with this key is still running.
+	ErrKeyInUse = errors.New("a request with this idempotency key is still in progress")
+	// ErrKeyReused means the key was already used with a different request.
+	ErrKeyReused = errors.New("idempotency key was already used with a different request")
+)
+
+// Middleware replays stored responses for repeated Idempotency-Key requests.
+type Middleware struct {
+	store  Store
+	ttl    time.Duration
+	logger *slog.Logger
+}
+
+// NewMiddleware creates the middleware. Responses are kept for ttl.
+func NewMiddleware(store Store, ttl time.Duration, logger *slog.Logger) *Middleware {
+	return &Middleware{store: store, ttl: ttl, logger: logger}
+}
+
+// Handler must run after authentication; keys are scoped to the signed-in
+// user. Requests without the header pass through unchanged. Only successful
+// responses are stored, so a failed request can be retried with the same key.
+func (m *Middleware) Handler(c *fiber.Ctx) error {
+	key := c.Get(Header)
+	if key == "" {
+		return c.Next()
+	}
+	if len(key) > maxKeyLength {
+		return apierror.Validation("invalid_idempotency_key", "Idempotency-Key must be at most 255 characters",
+			apierror.Field(Header, "is too long"))
+	}
+	userID, ok := c.Locals(auth.ContextUserIDKey).(string)
+	if !ok || userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	key = utils.CopyString(key)
+
+	now := time.Now().UTC()
+	existing, reserved, err := m.store.Reserve(Record{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash(c),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(m.ttl),
+	})
+	if err != nil {
+		return mapError(err)
+	}
+	if !reserved {
+		return m.replay(c, existing)
+	}
+
+	if err := c.Next(); err != nil {
+		m.release(c, userID, key)
+		return err
+	}
+
+	status := c.Response().StatusCode()
+	if status >= fiber.StatusBadRequest {
+		m.release(c, userID, key)
+		return nil
+	}
+	body := append([]byte(nil), c.Response().Body()...)
+	etag := string(c.Response().Header.Peek(fiber.HeaderETag))
+	if err := m.store.Complete(userID, key, status, body, etag); err != nil {
+		// The response itself succeeded; a later retry will simply run again.
+		m.logger.ErrorContext(c.UserContext(), "store idempotent response failed", "error", err)
+		m.release(c, userID, key)
+	}
+	return nil
+}
+
+func (m *Middleware) replay(c *fiber.Ctx, record Record) error {
+	if record.RequestHash != requestHash(c) {
+		return mapError(ErrKeyReused)
+	}
+	if !record.Completed() {
+		return mapError(ErrKeyInUse)
+	}
+
+	c.Set(ReplayedHeader, "true")
+	if record.ResponseETag != "" {
+		c.Set(fiber.HeaderETag, record.ResponseETag)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Status(record.StatusCode).Send(record.ResponseBody)
+}
+
+func (m *Middleware) release(c *fiber.Ctx, userID, key string) {
+	if err := m.store.Release(userID, key); err != nil {
+		m.logger.ErrorContext(c.UserContext(), "release idempotency key failed", "error", err)
+	}
+}
+
+// requestHash fingerprints the method, route and body so a key cannot be
+// replayed against a different request.
+func requestHash(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write(c.Request().Header.Method())
+	h.Write([]byte{0})
+	h.Write([]byte(routeKey(c)))
+	h.Write([]byte{0})
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// routeKey names the matched route pattern without its API version, so a
+// retry sent to /api/study-sessions matches an original sent to the same
+// route under /api/v1.
+func routeKey(c *fiber.Ctx) string {
+	path := c.Path()
+	if route := c.Route(); route != nil {
+		path = route.Path
+	}
+	rest, ok := strings.CutPrefix(path, "/api/")
+	if !ok {
+		return path
+	}
+	if version, tail, found := strings.Cut(rest, "/"); found && isVersion(version) {
+		return "/api/" + tail
+	}
+	return path
+}
+
+// isVersion reports whether segment looks like an API version such as v1.
+func isVersion(segment string) bool {
+	digits, ok := strings.CutPrefix(segment, "v")
+	if !ok || digits == "" {
+		return false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var errorMappings = []apierror.Mapping{
+	{Target: ErrKeyInUse, Status: fiber.StatusConflict, Code: "idempotency_key_in_use"},
+	{Target: ErrKeyReused, Status: fiber.StatusUnprocessableEntity, Code: "idempotency_key_reused"},
+}
+
+func mapError(err error) error {
+	return apierror.Map(err, errorMappings...)
+}
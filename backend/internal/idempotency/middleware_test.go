@@ -0,0 +1,203 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"studytracker/internal/auth"
+	"studytracker/internal/platform/apierror"
+	"studytracker/internal/platform/database"
+)
+
+type testServer struct {
+	app     *fiber.App
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+// newTestServer mounts a create endpoint behind the middleware under both
+// /api/v1 and the /api alias. /items/fail fails its first call and /items/slow
+// signals started, then waits for release.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	db, err := database.Open(database.Config{DSN: "file::memory:?_pragma=foreign_keys(ON)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	if err := database.ApplyMigrations(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"user-1", "user-2"} {
+		if _, err := db.Exec(`INSERT INTO users (id, email, provider, created_at, updated_at) VALUES (?, ?, 'local', ?, ?)`,
+			id, id+"@example.com", time.Now(), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	srv := &testServer{started: make(chan struct{}, 1), release: make(chan struct{})}
+	srv.app = fiber.New(fiber.Config{ErrorHandler: apierror.Handler(logger)})
+	middleware := NewMiddleware(NewSQLStore(db), time.Hour, logger)
+
+	signIn := func(c *fiber.Ctx) error {
+		c.Locals(auth.ContextUserIDKey, c.Get("X-User"))
+		return c.Next()
+	}
+	create := func(c *fiber.Ctx) error {
+		n := srv.calls.Add(1)
+		c.Set(fiber.HeaderETag, fmt.Sprintf(`"%d"`, n))
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"call": n})
+	}
+	for _, prefix := range []string{"/api/v1", "/api"} {
+		srv.app.Post(prefix+"/items", signIn, middleware.Handler, create)
+		srv.app.Post(prefix+"/items/fail", signIn, middleware.Handler, func(c *fiber.Ctx) error {
+			if srv.calls.Add(1) == 1 {
+				return fiber.NewError(fiber.StatusBadRequest, "try again")
+			}
+			return c.Status(fiber.StatusCreated).JSON(fiber.Map{"ok": true})
+		})
+		srv.app.Post(prefix+"/items/slow", signIn, middleware.Handler, func(c *fiber.Ctx) error {
+			srv.started <- struct{}{}
+			<-srv.release
+			return create(c)
+		})
+	}
+	return srv
+}
+
+type result struct {
+	status   int
+	body     string
+	code     string
+	replayed bool
+	etag     string
+}
+
+func (s *testServer) post(t *testing.T, path, user, key, body string) result {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set("X-User", user)
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	var envelope struct {
+		Code string `json:"code"`
+	}
+	json.Unmarshal(raw, &envelope)
+	return result{
+		status:   resp.StatusCode,
+		body:     string(raw),
+		code:     envelope.Code,
+		replayed: resp.Header.Get(ReplayedHeader) == "true",
+		etag:     resp.Header.Get(fiber.HeaderETag),
+	}
+}
+
+func TestMiddlewareReplaysRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		retryPath    string
+		retryUser    string
+		retryBody    string
+		wantStatus   int
+		wantCode     string
+		wantReplayed bool
+		wantCalls    int32
+	}{
+		{name: "same request", retryPath: "/api/v1/items", retryUser: "user-1", retryBody: `{"a":1}`,
+			wantStatus: fiber.StatusCreated, wantReplayed: true, wantCalls: 1},
+		{name: "through the unversioned alias", retryPath: "/api/items", retryUser: "user-1", retryBody: `{"a":1}`,
+			wantStatus: fiber.StatusCreated, wantReplayed: true, wantCalls: 1},
+		{name: "different body", retryPath: "/api/v1/items", retryUser: "user-1", retryBody: `{"a":2}`,
+			wantStatus: fiber.StatusUnprocessableEntity, wantCode: "idempotency_key_reused", wantCalls: 1},
+		{name: "different route", retryPath: "/api/v1/items/fail", retryUser: "user-1", retryBody: `{"a":1}`,
+			wantStatus: fiber.StatusUnprocessableEntity, wantCode: "idempotency_key_reused", wantCalls: 1},
+		{name: "other user's key", retryPath: "/api/v1/items", retryUser: "user-2", retryBody: `{"a":1}`,
+			wantStatus: fiber.StatusCreated, wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t)
+			first := srv.post(t, "/api/v1/items", "user-1", "key-1", `{"a":1}`)
+			if first.status != fiber.StatusCreated || first.replayed {
+				t.Fatalf("first request: %+v", first)
+			}
+
+			retry := srv.post(t, tt.retryPath, tt.retryUser, "key-1", tt.retryBody)
+			if retry.status != tt.wantStatus || retry.code != tt.wantCode || retry.replayed != tt.wantReplayed {
+				t.Fatalf("retry = %+v, want status %d code %q replayed %v", retry, tt.wantStatus, tt.wantCode, tt.wantReplayed)
+			}
+			if tt.wantReplayed && (retry.body != first.body || retry.etag != first.etag) {
+				t.Fatalf("replayed %s with ETag %s, want %s with %s", retry.body, retry.etag, first.body, first.etag)
+			}
+			if got := srv.calls.Load(); got != tt.wantCalls {
+				t.Fatalf("handler ran %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestMiddlewareWithoutKeyAlwaysRuns(t *testing.T) {
+	srv := newTestServer(t)
+	srv.post(t, "/api/v1/items", "user-1", "", `{}`)
+	srv.post(t, "/api/v1/items", "user-1", "", `{}`)
+	if got := srv.calls.Load(); got != 2 {
+		t.Fatalf("handler ran %d times, want 2", got)
+	}
+}
+
+func TestMiddlewareReleasesFailedRequests(t *testing.T) {
+	srv := newTestServer(t)
+	if first := srv.post(t, "/api/v1/items/fail", "user-1", "key-1", `{}`); first.status != fiber.StatusBadRequest {
+		t.Fatalf("first request: %+v", first)
+	}
+	retry := srv.post(t, "/api/v1/items/fail", "user-1", "key-1", `{}`)
+	if retry.status != fiber.StatusCreated || retry.replayed {
+		t.Fatalf("retry after failure = %+v, want it to run again", retry)
+	}
+}
+
+func TestMiddlewareRejectsKeyInUse(t *testing.T) {
+	srv := newTestServer(t)
+	done := make(chan result, 1)
+	go func() { done <- srv.post(t, "/api/v1/items/slow", "user-1", "key-1", `{}`) }()
+
+	<-srv.started
+	second := srv.post(t, "/api/v1/items/slow", "user-1", "key-1", `{}`)
+	close(srv.release)
+	if second.status != fiber.StatusConflict || second.code != "idempotency_key_in_use" {
+		t.Fatalf("concurrent retry = %+v, want 409 idempotency_key_in_use", second)
+	}
+	if first := <-done; first.status != fiber.StatusCreated {
+		t.Fatalf("original request = %+v", first)
+	}
+}
+
+func TestMiddlewareRejectsLongKey(t *testing.T) {
+	srv := newTestServer(t)
+	got := srv.post(t, "/api/v1/items", "user-1", strings.Repeat("k", maxKeyLength+1), `{}`)
+	if got.status != fiber.StatusBadRequest || got.code != "invalid_idempotency_key" {
+		t.Fatalf("long key = %+v, want 400 invalid_idempotency_key", got)
+	}
+}
@@ -0,0 +1,150 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"studytracker/internal/platform/database"
+)
+
+// Record is a stored Idempotency-Key. A zero StatusCode means the original
+// request is still being processed.
+type Record struct {
+	UserID       string
+	Key          string
+	RequestHash  string
+	StatusCode   int
+	ResponseBody []byte
+	ResponseETag string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// Completed reports whether the original response has been stored.
+func (r Record) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// Store persists idempotency keys per user.
+type Store interface {
+	// Reserve claims record's key. When the key is already held (and not
+	// expired) the existing record is returned with reserved set to false.
+	Reserve(record Record) (existing Record, reserved bool, err error)
+	Complete(userID, key string, status int, body []byte, etag string) error
+	Release(userID, key string) error
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+// SQLStore implements Store on the application database.
+type SQLStore struct {
+	db        *sql.DB
+	useDollar bool
+}
+
+// NewSQLStore creates a store backed by db.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{
+		db:        db,
+		useDollar: database.UsesDollarPlaceholders(db),
+	}
+}
+
+func (s *SQLStore) Reserve(record Record) (Record, bool, error) {
+	ctx := context.Background()
+
+	// An expired key is free to be claimed again.
+	const purge = `DELETE FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ? AND expires_at <= ?;`
+	if _, err := s.db.ExecContext(ctx, s.rebind(purge), record.UserID, record.Key, record.CreatedAt.UTC()); err != nil {
+		return Record{}, false, err
+	}
+
+	const insert = `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, status_code, created_at, expires_at)
+		VALUES (?, ?, ?, 0, ?, ?)
+		ON CONFLICT (user_id, idempotency_key) DO NOTHING;
+	`
+	res, err := s.db.ExecContext(ctx, s.rebind(insert),
+		record.UserID,
+		record.Key,
+		record.RequestHash,
+		record.CreatedAt.UTC(),
+		record.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return Record{}, false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return Record{}, false, err
+	}
+	if rows == 1 {
+		return record, true, nil
+	}
+
+	existing, err := s.get(ctx, record.UserID, record.Key)
+	if err != nil {
+		return Record{}, false, err
+	}
+	return existing, false, nil
+}
+
+func (s *SQLStore) Complete(userID, key string, status int, body []byte, etag string) error {
+	const query = `
+		UPDATE idempotency_keys
+		SET status_code = ?, response_body = ?, response_etag = ?
+		WHERE user_id = ? AND idempotency_key = ?;
+	`
+	_, err := s.db.ExecContext(context.Background(), s.rebind(query), status, string(body), etag, userID, key)
+	return err
+}
+
+func (s *SQLStore) Release(userID, key string) error {
+	const query = `DELETE FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ? AND status_code = 0;`
+	_, err := s.db.ExecContext(context.Background(), s.rebind(query), userID, key)
+	return err
+}
+
+func (s *SQLStore) DeleteExpired(now time.Time) (int64, error) {
+	const query = `DELETE FROM idempotency_keys WHERE expires_at < ?;`
+	res, err := s.db.ExecContext(context.Background(), s.rebind(query), now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *SQLStore) get(ctx context.Context, userID, key string) (Record, error) {
+	const query = `
+		SELECT user_id, idempotency_key, request_hash, status_code, response_body, response_etag, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = ? AND idempotency_key = ?;
+	`
+	var record Record
+	var body, etag sql.NullString
+	err := s.db.QueryRowContext(ctx, s.rebind(query), userID, key).Scan(
+		&record.UserID,
+		&record.Key,
+		&record.RequestHash,
+		&record.StatusCode,
+		&body,
+		&etag,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Released or purged between the insert and this read.
+			return Record{}, ErrKeyInUse
+		}
+		return Record{}, err
+	}
+	record.ResponseBody = []byte(body.String)
+	record.ResponseETag = etag.String
+	return record, nil
+}
+
+func (s *SQLStore) rebind(query string) string {
+	return database.Rebind(query, s.useDollar)
+}
@@ -0,0 +1,44 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Undocumented returns "METHOD /path" for every route registered under
+// basePath that doc does not describe. Paths listed in skip (relative to
+// basePath) are ignored. HEAD routes that Fiber adds for GET are skipped too.
+func Undocumented(doc Document, routes []fiber.Route, basePath string, skip ...string) []string {
+	ignored := make(map[string]bool, len(skip))
+	for _, path := range skip {
+		ignored[path] = true
+	}
+
+	seen := make(map[string]bool)
+	var missing []string
+	for _, route := range routes {
+		if route.Method == http.MethodHead || !strings.HasPrefix(route.Path, basePath+"/") {
+			continue
+		}
+		rel := strings.TrimPrefix(route.Path, basePath)
+		if ignored[rel] {
+			continue
+		}
+		oaPath, _ := convertPath(rel)
+		if item, ok := doc.Paths[oaPath]; ok {
+			if _, ok := item[strings.ToLower(route.Method)]; ok {
+				continue
+			}
+		}
+		key := route.Method + " " + route.Path
+		if !seen[key] {
+			seen[key] = true
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,47 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// Handler serves the OpenAPI document and a browsable docs page.
+type Handler struct {
+	spec []byte
+}
+
+// NewHandler encodes doc once so every request serves the same bytes.
+func NewHandler(doc Document) (*Handler, error) {
+	spec, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{spec: spec}, nil
+}
+
+// Paths served by RegisterRoutes, relative to the router they are mounted on.
+const (
+	SpecPath = "/openapi.json"
+	DocsPath = "/docs"
+)
+
+// RegisterRoutes mounts the spec and docs page. Neither requires authentication.
+func (h *Handler) RegisterRoutes(router fiber.Router) {
+	router.Get(SpecPath, h.serveSpec)
+	router.Get(DocsPath, h.serveDocs)
+}
+
+func (h *Handler) serveSpec(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(h.spec)
+}
+
+func (h *Handler) serveDocs(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(docsPage)
+}
@@ -0,0 +1,682 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"studytracker/internal/platform/apierror"
+	"studytracker/internal/study"
+	"studytracker/internal/user"
+)
+
+const (
+	tagAuth        = "auth"
+	tagSubjects    = "subjects"
+	tagSessions    = "study-sessions"
+	tagProgress    = "progress"
+	tagSync        = "sync"
+	tagTrash       = "trash"
+	tagTags        = "tags"
+	tagPomodoro    = "pomodoro"
+	tagPreferences = "preferences"
+)
+
+// credentials mirrors the login and registration payload.
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// Build assembles the OpenAPI document for every route under basePath.
+func Build(basePath string) Document {
+	b := newBuilder(basePath)
+
+	b.describeAuth()
+	b.describeSubjects()
+	b.describeSessions()
+	b.describeTags()
+	b.describePomodoro()
+	b.describePreferences()
+	b.describeProgress()
+	b.describeSync()
+	b.describeTrash()
+
+	b.doc.Components.Schemas = b.schemas.schemas
+	return b.doc
+}
+
+type builder struct {
+	doc     Document
+	schemas *schemaRegistry
+	errRef  *Schema
+}
+
+func newBuilder(basePath string) *builder {
+	b := &builder{
+		doc: Document{
+			OpenAPI: "3.0.3",
+			Info: Info{
+				Title:       "Study Tracker API",
+				Version:     "1.0.0",
+				Description: "Log study sessions, manage subjects and read progress summaries.",
+			},
+			Servers: []Server{{URL: basePath}},
+			Paths:   make(map[string]PathItem),
+			Components: Components{
+				SecuritySchemes: map[string]SecurityScheme{
+					"sessionCookie": {
+						Type:        "apiKey",
+						In:          "cookie",
+						Name:        "session_token",
+						Description: "HTTP-only cookie set by /auth/login and /auth/register.",
+					},
+				},
+			},
+			Security: []map[string][]string{{"sessionCookie": {}}},
+			Tags: []Tag{
+				{Name: tagAuth, Description: "Registration, login and the current user."},
+				{Name: tagSubjects, Description: "The subject catalogue."},
+				{Name: tagSessions, Description: "Logged study sessions."},
+				{Name: tagProgress, Description: "Aggregated statistics."},
+				{Name: tagSync, Description: "Incremental sync for offline clients."},
+				{Name: tagTrash, Description: "Deleted items that can still be restored."},
+				{Name: tagTags, Description: "Labels shared across sessions."},
+				{Name: tagPomodoro, Description: "Pomodoro timings and statistics."},
+				{Name: tagPreferences, Description: "Per-user study preferences."},
+			},
+		},
+		schemas: newSchemaRegistry(),
+	}
+	b.errRef = b.schemas.named("Error", apierror.Body{})
+	return b
+}
+
+// add registers an operation. path uses Fiber syntax (":id") and is converted
+// to OpenAPI templating ("{id}"), adding the matching path parameters.
+func (b *builder) add(method, path string, op *Operation) {
+	oaPath, params := convertPath(path)
+	op.Parameters = append(params, op.Parameters...)
+	if _, ok := op.Responses["default"]; !ok {
+		op.Responses["default"] = b.errorResponse("Error envelope with a machine-readable code.")
+	}
+	item, ok := b.doc.Paths[oaPath]
+	if !ok {
+		item = make(PathItem)
+		b.doc.Paths[oaPath] = item
+	}
+	item[strings.ToLower(method)] = op
+}
+
+func (b *builder) errorResponse(description string) Response {
+	return Response{Description: description, Content: jsonContent(b.errRef)}
+}
+
+func (b *builder) errors(statuses ...int) map[string]Response {
+	responses := make(map[string]Response, len(statuses))
+	for _, status := range statuses {
+		responses[strconv.Itoa(status)] = b.errorResponse(http.StatusText(status))
+	}
+	return responses
+}
+
+func (b *builder) describeAuth() {
+	public := []map[string][]string{}
+	userRef := b.schemas.named("User", user.User{})
+	credsRef := b.schemas.named("Credentials", credentials{})
+
+	b.add(http.MethodPost, "/auth/register", &Operation{
+		OperationID: "register",
+		Summary:     "Create an account and start a session",
+		Tags:        []string{tagAuth},
+		Security:    public,
+		RequestBody: jsonBody(credsRef),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusConflict), map[string]Response{
+			"201": {Description: "Account created; the session cookie is set.", Content: jsonContent(userRef)},
+		}),
+	})
+	b.add(http.MethodPost, "/auth/login", &Operation{
+		OperationID: "login",
+		Summary:     "Sign in with email and password",
+		Tags:        []string{tagAuth},
+		Security:    public,
+		RequestBody: jsonBody(credsRef),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "Signed in; the session cookie is set.", Content: jsonContent(userRef)},
+		}),
+	})
+	b.add(http.MethodPost, "/auth/logout", &Operation{
+		OperationID: "logout",
+		Summary:     "End the current session",
+		Tags:        []string{tagAuth},
+		Security:    public,
+		Responses:   map[string]Response{"204": {Description: "Session cleared."}},
+	})
+	b.add(http.MethodGet, "/auth/google/login", &Operation{
+		OperationID: "googleLogin",
+		Summary:     "Start Google Sign-In",
+		Tags:        []string{tagAuth},
+		Security:    public,
+		Responses: merge(b.errors(http.StatusNotImplemented), map[string]Response{
+			"200": {Description: "Authorization URL to redirect the browser to.", Content: jsonContent(&Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"url": {Type: "string", Format: "uri"}},
+			})},
+		}),
+	})
+	b.add(http.MethodGet, "/auth/google/callback", &Operation{
+		OperationID: "googleCallback",
+		Summary:     "Complete Google Sign-In",
+		Tags:        []string{tagAuth},
+		Security:    public,
+		Parameters: []Parameter{
+			{Name: "state", In: "query", Required: true, Schema: &Schema{Type: "string"}},
+			{Name: "code", In: "query", Required: true, Schema: &Schema{Type: "string"}},
+		},
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotImplemented), map[string]Response{
+			"307": {Description: "Signed in; redirects to the frontend."},
+		}),
+	})
+	b.add(http.MethodGet, "/auth/me", &Operation{
+		OperationID: "getCurrentUser",
+		Summary:     "Return the signed-in user",
+		Tags:        []string{tagAuth},
+		Responses: merge(b.errors(http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "The current user.", Content: jsonContent(userRef)},
+		}),
+	})
+}
+
+func (b *builder) describeSubjects() {
+	subjectRef := b.schemas.ref(study.Subject{})
+
+	b.add(http.MethodGet, "/subjects", &Operation{
+		OperationID: "listSubjects",
+		Summary:     "List subjects with session totals",
+		Description: "Archived subjects are left out unless includeArchived is true; their totals still count towards their ancestors' rollups.",
+		Tags:        []string{tagSubjects},
+		Parameters:  []Parameter{{Name: "includeArchived", In: "query", Description: "Include archived subjects.", Schema: &Schema{Type: "boolean"}}, ifNoneMatch},
+		Responses: merge(b.errors(http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "Subjects ordered by name.", Content: jsonContent(arrayOf(subjectRef)), Headers: etagHeader},
+			"304": {Description: "The list is unchanged since the If-None-Match tag."},
+		}),
+	})
+	b.add(http.MethodGet, "/subjects/tree", &Operation{
+		OperationID: "getSubjectTree",
+		Summary:     "List subjects nested under their parents",
+		Description: "Each level is ordered by name. rollupMinutes and rollupSessionCount include every descendant. Subjects whose parent is archived and filtered out appear at the top level.",
+		Tags:        []string{tagSubjects},
+		Parameters:  []Parameter{{Name: "includeArchived", In: "query", Description: "Include archived subjects.", Schema: &Schema{Type: "boolean"}}, ifNoneMatch},
+		Responses: merge(b.errors(http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "Top-level subjects with their children.", Content: jsonContent(arrayOf(b.schemas.ref(study.SubjectNode{}))), Headers: etagHeader},
+			"304": {Description: "The tree is unchanged since the If-None-Match tag."},
+		}),
+	})
+	b.add(http.MethodPost, "/subjects", &Operation{
+		OperationID: "createSubject",
+		Summary:     "Create a subject",
+		Description: "parentId optionally places the subject below another one.",
+		Tags:        []string{tagSubjects},
+		Parameters:  []Parameter{idempotencyKey},
+		RequestBody: jsonBody(subjectRef),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusUnprocessableEntity), map[string]Response{
+			"201": {Description: "The created subject.", Content: jsonContent(subjectRef), Headers: etagHeader},
+		}),
+	})
+	b.add(http.MethodPut, "/subjects/:id", &Operation{
+		OperationID: "updateSubject",
+		Summary:     "Rename or recolour a subject",
+		Description: "A new name is copied to the subject's sessions in the same transaction. parentId is ignored; use the move endpoint to change it.",
+		Tags:        []string{tagSubjects},
+		Parameters:  []Parameter{ifMatch},
+		RequestBody: jsonBody(subjectRef),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed), map[string]Response{
+			"200": {Description: "The updated subject.", Content: jsonContent(subjectRef), Headers: etagHeader},
+		}),
+	})
+	b.add(http.MethodPatch, "/subjects/:id", &Operation{
+		OperationID: "patchSubject",
+		Summary:     "Change selected subject fields with a JSON Merge Patch",
+		Tags:        []string{tagSubjects},
+		Parameters:  []Parameter{ifMatch},
+		RequestBody: mergePatchBody(subjectRef),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnsupportedMediaType), map[string]Response{
+			"200": {Description: "The updated subject.", Content: jsonContent(subjectRef), Headers: etagHeader},
+		}),
+	})
+	b.add(http.MethodDelete, "/subjects/:id", &Operation{
+		OperationID: "deleteSubject",
+		Summary:     "Move a subject to the trash",
+		Description: "Subjects with children are rejected with 409; move or delete the children first.",
+		Tags:        []string{tagSubjects},
+		Parameters:  []Parameter{ifMatch},
+		Responses: merge(b.errors(http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed), map[string]Response{
+			"204": {Description: "Subject deleted."},
+		}),
+	})
+	b.add(http.MethodPost, "/subjects/:id/archive", &Operation{
+		OperationID: "archiveSubject",
+		Summary:     "Retire a subject",
+		Description: "Archived subjects keep their sessions, history and analytics, but logging a new session against one, or moving a session onto one, is rejected with 409 subject_archived.",
+		Tags:        []string{tagSubjects},
+		Parameters:  []Parameter{ifMatch},
+		Responses: merge(b.errors(http.StatusUnauthorized, http.StatusNotFound, http.StatusPreconditionFailed), map[string]Response{
+			"200": {Description: "The archived subject.", Content: jsonContent(subjectRef), Headers: etagHeader},
+		}),
+	})
+	b.add(http.MethodPost, "/subjects/:id/unarchive", &Operation{
+		OperationID: "unarchiveSubject",
+		Summary:     "Make an archived subject available again",
+		Tags:        []string{tagSubjects},
+		Parameters:  []Parameter{ifMatch},
+		Responses: merge(b.errors(http.StatusUnauthorized, http.StatusNotFound, http.StatusPreconditionFailed), map[string]Response{
+			"200": {Description: "The unarchived subject.", Content: jsonContent(subjectRef), Headers: etagHeader},
+		}),
+	})
+	b.add(http.MethodGet, "/subjects/duplicates", &Operation{
+		OperationID: "listSubjectDuplicates",
+		Summary:     "Suggest subjects that look like duplicates",
+		Description: "Pairs whose names match ignoring case and punctuation, abbreviate one another (Lin Alg, Linear Algebra), are initials of one another (LA, Linear Algebra) or differ by a small edit distance. target is the subject with more sessions.",
+		Tags:        []string{tagSubjects},
+		Parameters:  []Parameter{ifNoneMatch},
+		Responses: merge(b.errors(http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "Suggestions, most alike first.", Content: jsonContent(arrayOf(b.schemas.ref(study.DuplicateSuggestion{}))), Headers: etagHeader},
+			"304": {Description: "The suggestions are unchanged since the If-None-Match tag."},
+		}),
+	})
+	b.add(http.MethodPost, "/subjects/:id/merge-into/:targetId", &Operation{
+		OperationID: "mergeSubject",
+		Summary:     "Fold a subject into another",
+		Description: "In one transaction, every session of the subject, trashed ones included, moves to the target under its name, child subjects move under the target, the target takes the subject's colour if it has none, and the subject moves to the trash.",
+		Tags:        []string{tagSubjects},
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound), map[string]Response{
+			"200": {Description: "The target subject with its new totals.", Content: jsonContent(subjectRef), Headers: etagHeader},
+		}),
+	})
+	b.add(http.MethodPost, "/subjects/:id/move", &Operation{
+		OperationID: "moveSubject",
+		Summary:     "Put a subject under another parent",
+		Description: "An empty parentId moves the subject to the top level. Moving a subject below itself or one of its descendants is rejected with 409.",
+		Tags:        []string{tagSubjects},
+		Parameters:  []Parameter{ifMatch},
+		RequestBody: jsonBody(b.schemas.ref(study.MoveSubjectRequest{})),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed), map[string]Response{
+			"200": {Description: "The moved subject.", Content: jsonContent(subjectRef), Headers: etagHeader},
+		}),
+	})
+}
+
+func (b *builder) describeSessions() {
+	sessionRef := b.schemas.ref(study.StudySession{})
+
+	b.add(http.MethodGet, "/study-sessions", &Operation{
+		OperationID: "listStudySessions",
+		Summary:     "List study sessions, newest first",
+		Tags:        []string{tagSessions},
+		Parameters: []Parameter{
+			{Name: "tag", In: "query", Description: "Only sessions carrying this tag; repeat to require several.", Schema: arrayOf(&Schema{Type: "string"})},
+			ifNoneMatch,
+		},
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "The user's sessions.", Content: jsonContent(arrayOf(sessionRef)), Headers: etagHeader},
+			"304": {Description: "The list is unchanged since the If-None-Match tag."},
+		}),
+	})
+	b.add(http.MethodPost, "/study-sessions", &Operation{
+		OperationID: "createStudySession",
+		Summary:     "Log a study session",
+		Description: "Send either startTime and endTime, or segments. A session with segments spans them and its duration counts focus segments only, so pauses between them are excluded. Overlaps with other sessions are rejected with 409 or listed in overlapsWith, depending on the overlap policy.",
+		Tags:        []string{tagSessions},
+		Parameters:  []Parameter{idempotencyKey},
+		RequestBody: jsonBody(sessionRef),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusUnprocessableEntity), map[string]Response{
+			"201": {Description: "The created session with its computed duration.", Content: jsonContent(sessionRef), Headers: etagHeader},
+		}),
+	})
+	b.add(http.MethodPost, "/study-sessions/batch", &Operation{
+		OperationID: "batchStudySessions",
+		Summary:     "Create, update and delete many sessions in one transaction",
+		Description: "With atomic set, any failed operation rolls back the whole batch; otherwise each operation succeeds or fails on its own. Update data is applied as a JSON Merge Patch.",
+		Tags:        []string{tagSessions},
+		Parameters:  []Parameter{idempotencyKey},
+		RequestBody: jsonBody(b.schemas.ref(study.BatchRequest{})),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity), map[string]Response{
+			"200": {Description: "Per-operation results and whether the batch committed.", Content: jsonContent(b.schemas.ref(study.BatchResponse{}))},
+		}),
+	})
+	b.add(http.MethodPut, "/study-sessions/:id", &Operation{
+		OperationID: "updateStudySession",
+		Summary:     "Replace a study session",
+		Description: "Leaving out segments keeps them only when startTime and endTime are left out too; new times without segments make the session a single block.",
+		Tags:        []string{tagSessions},
+		Parameters:  []Parameter{ifMatch},
+		RequestBody: jsonBody(sessionRef),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed), map[string]Response{
+			"200": {Description: "The updated session.", Content: jsonContent(sessionRef), Headers: etagHeader},
+		}),
+	})
+	b.add(http.MethodPatch, "/study-sessions/:id", &Operation{
+		OperationID: "patchStudySession",
+		Summary:     "Change selected session fields with a JSON Merge Patch",
+		Description: "Fields omitted from the patch keep their stored values; null clears a field. The merged session is validated and its duration recomputed.",
+		Tags:        []string{tagSessions},
+		Parameters:  []Parameter{ifMatch},
+		RequestBody: mergePatchBody(sessionRef),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnsupportedMediaType), map[string]Response{
+			"200": {Description: "The updated session.", Content: jsonContent(sessionRef), Headers: etagHeader},
+		}),
+	})
+	b.add(http.MethodDelete, "/study-sessions/:id", &Operation{
+		OperationID: "deleteStudySession",
+		Summary:     "Move a study session to the trash",
+		Tags:        []string{tagSessions},
+		Parameters:  []Parameter{ifMatch},
+		Responses: merge(b.errors(http.StatusUnauthorized, http.StatusNotFound, http.StatusPreconditionFailed), map[string]Response{
+			"204": {Description: "Session deleted."},
+		}),
+	})
+	b.add(http.MethodGet, "/study-sessions/:id/history", &Operation{
+		OperationID: "getStudySessionHistory",
+		Summary:     "List a session's revisions",
+		Description: "Every create, update, delete, restore and revert with before and after snapshots, oldest first. Deleted sessions keep their history.",
+		Tags:        []string{tagSessions},
+		Parameters:  []Parameter{ifNoneMatch},
+		Responses: merge(b.errors(http.StatusUnauthorized, http.StatusNotFound), map[string]Response{
+			"200": {Description: "Revisions ordered by revision number.", Content: jsonContent(arrayOf(b.schemas.ref(study.SessionRevision{}))), Headers: etagHeader},
+			"304": {Description: "The history is unchanged since the If-None-Match tag."},
+		}),
+	})
+	b.add(http.MethodPost, "/study-sessions/:id/revert", &Operation{
+		OperationID: "revertStudySession",
+		Summary:     "Return a session to the state after a revision",
+		Description: "Applies the revision's after snapshot as a regular update, so it is validated and recorded as a new revert revision.",
+		Tags:        []string{tagSessions},
+		Parameters:  []Parameter{ifMatch},
+		RequestBody: jsonBody(b.schemas.ref(study.RevertRequest{})),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed), map[string]Response{
+			"200": {Description: "The reverted session.", Content: jsonContent(sessionRef), Headers: etagHeader},
+		}),
+	})
+	b.add(http.MethodPost, "/study-sessions/:id/split", &Operation{
+		OperationID: "splitStudySession",
+		Summary:     "Split a session in two at a timestamp",
+		Description: "The session keeps the part before at and a new session is created for the rest; firstSubject and secondSubject optionally move either part. Both writes are recorded as split revisions.",
+		Tags:        []string{tagSessions},
+		Parameters:  []Parameter{ifMatch},
+		RequestBody: jsonBody(b.schemas.ref(study.SplitRequest{})),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed), map[string]Response{
+			"201": {Description: "The two parts, earliest first.", Content: jsonContent(arrayOf(sessionRef))},
+		}),
+	})
+	b.add(http.MethodPost, "/study-sessions/merge", &Operation{
+		OperationID: "mergeStudySessions",
+		Summary:     "Combine adjacent sessions of one subject",
+		Description: "Sessions are folded into the earliest one, with notes and reflections concatenated and tags combined; the others move to the trash. No other session may lie between them. Every write is recorded as a merge revision.",
+		Tags:        []string{tagSessions},
+		RequestBody: jsonBody(b.schemas.ref(study.MergeRequest{})),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict), map[string]Response{
+			"200": {Description: "The merged session.", Content: jsonContent(sessionRef), Headers: etagHeader},
+		}),
+	})
+	b.add(http.MethodGet, "/study-sessions/overlaps", &Operation{
+		OperationID: "listStudySessionOverlaps",
+		Summary:     "List pairs of sessions that cover the same time",
+		Description: "Sessions with segments only occupy their segments, breaks included. Pairs are ordered by the first session's start time.",
+		Tags:        []string{tagSessions},
+		Parameters:  []Parameter{ifNoneMatch},
+		Responses: merge(b.errors(http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "Overlapping pairs.", Content: jsonContent(arrayOf(b.schemas.ref(study.OverlapPair{}))), Headers: etagHeader},
+			"304": {Description: "The pairs are unchanged since the If-None-Match tag."},
+		}),
+	})
+	b.add(http.MethodPost, "/study-sessions/overlaps/resolve", &Operation{
+		OperationID: "resolveStudySessionOverlap",
+		Summary:     "Merge an overlapping pair, or split one around the other",
+		Description: "merge folds both into the earlier session and moves the other to the trash; split removes the later session's span from the earlier one, creating a second part when it is cut in the middle. Every change is recorded in the sessions' history.",
+		Tags:        []string{tagSessions},
+		RequestBody: jsonBody(b.schemas.ref(study.ResolveOverlapRequest{})),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict), map[string]Response{
+			"200": {Description: "The resulting sessions, earliest first.", Content: jsonContent(arrayOf(sessionRef))},
+		}),
+	})
+}
+
+func (b *builder) describeTags() {
+	tagRef := b.schemas.ref(study.Tag{})
+
+	b.add(http.MethodGet, "/tags", &Operation{
+		OperationID: "listTags",
+		Summary:     "List or autocomplete tags, most used first",
+		Tags:        []string{tagTags},
+		Parameters: []Parameter{
+			{Name: "q", In: "query", Description: "Only tags starting with this prefix.", Schema: &Schema{Type: "string"}},
+			{Name: "limit", In: "query", Description: "Maximum tags to return (default 20, at most 100).", Schema: &Schema{Type: "integer"}},
+			ifNoneMatch,
+		},
+		Responses: merge(b.errors(http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "Tags with totals from live sessions.", Content: jsonContent(arrayOf(tagRef)), Headers: etagHeader},
+			"304": {Description: "The list is unchanged since the If-None-Match tag."},
+		}),
+	})
+	b.add(http.MethodPut, "/tags/:id", &Operation{
+		OperationID: "renameTag",
+		Summary:     "Rename a tag on every session carrying it",
+		Description: "Only name is read from the body. Renaming onto an existing tag fails with 409; merge the tags instead.",
+		Tags:        []string{tagTags},
+		RequestBody: jsonBody(tagRef),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict), map[string]Response{
+			"200": {Description: "The renamed tag.", Content: jsonContent(tagRef)},
+		}),
+	})
+	b.add(http.MethodPost, "/tags/:id/merge-into/:targetId", &Operation{
+		OperationID: "mergeTags",
+		Summary:     "Move every session from one tag to another and delete the first",
+		Tags:        []string{tagTags},
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound), map[string]Response{
+			"200": {Description: "The target tag with its new totals.", Content: jsonContent(tagRef)},
+		}),
+	})
+}
+
+func (b *builder) describePomodoro() {
+	settingsRef := b.schemas.ref(study.PomodoroSettings{})
+
+	b.add(http.MethodGet, "/pomodoro/settings", &Operation{
+		OperationID: "getPomodoroSettings",
+		Summary:     "Get the user's work and break lengths",
+		Tags:        []string{tagPomodoro},
+		Responses: merge(b.errors(http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "Saved settings, or the defaults (25/5/15, long break every 4).", Content: jsonContent(settingsRef)},
+		}),
+	})
+	b.add(http.MethodPut, "/pomodoro/settings", &Operation{
+		OperationID: "savePomodoroSettings",
+		Summary:     "Replace the user's work and break lengths",
+		Description: "Applies to segments recorded from now on; existing sessions keep their planned lengths.",
+		Tags:        []string{tagPomodoro},
+		RequestBody: jsonBody(settingsRef),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "The saved settings.", Content: jsonContent(settingsRef)},
+		}),
+	})
+	b.add(http.MethodGet, "/pomodoro/stats", &Operation{
+		OperationID: "getPomodoroStats",
+		Summary:     "Completed pomodoros, interruptions and break compliance",
+		Tags:        []string{tagPomodoro},
+		Parameters:  []Parameter{ifNoneMatch},
+		Responses: merge(b.errors(http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "Statistics over all Pomodoro sessions.", Content: jsonContent(b.schemas.ref(study.PomodoroStats{})), Headers: etagHeader},
+			"304": {Description: "The statistics are unchanged since the If-None-Match tag."},
+		}),
+	})
+}
+
+func (b *builder) describePreferences() {
+	prefsRef := b.schemas.ref(study.Preferences{})
+
+	b.add(http.MethodGet, "/preferences", &Operation{
+		OperationID: "getPreferences",
+		Summary:     "Get the user's study preferences",
+		Tags:        []string{tagPreferences},
+		Responses: merge(b.errors(http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "Saved preferences, or the defaults (overlapPolicy warn).", Content: jsonContent(prefsRef)},
+		}),
+	})
+	b.add(http.MethodPut, "/preferences", &Operation{
+		OperationID: "savePreferences",
+		Summary:     "Replace the user's study preferences",
+		Description: "overlapPolicy decides whether a session overlapping another is rejected, logged with a warning in overlapsWith, or logged silently.",
+		Tags:        []string{tagPreferences},
+		RequestBody: jsonBody(prefsRef),
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "The saved preferences.", Content: jsonContent(prefsRef)},
+		}),
+	})
+}
+
+func (b *builder) describeProgress() {
+	summaryRef := b.schemas.ref(study.ProgressSummary{})
+
+	b.add(http.MethodGet, "/progress/summary", &Operation{
+		OperationID: "getProgressSummary",
+		Summary:     "Aggregate totals, trends and streaks",
+		Description: "bySubject, overall and per day, is keyed by subject ID so renaming a subject never splits its totals; subjectNames maps each ID to the subject's current name.",
+		Tags:        []string{tagProgress},
+		Parameters: []Parameter{
+			{Name: "subjectDepth", In: "query", Description: "Group bySubject under ancestors at this depth: 1 for top-level subjects, 2 for their children. 0, the default, keeps each session's own subject.", Schema: &Schema{Type: "integer"}},
+			ifNoneMatch,
+		},
+		Responses: merge(b.errors(http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "Progress summary.", Content: jsonContent(summaryRef), Headers: etagHeader},
+			"304": {Description: "The summary is unchanged since the If-None-Match tag."},
+		}),
+	})
+	b.add(http.MethodGet, "/insights/ratings", &Operation{
+		OperationID: "getRatingInsights",
+		Summary:     "Average focus, mood, energy and difficulty ratings",
+		Description: "Averages per subject, local hour of day and day of week over rated sessions, plus how session length correlates with focus. bySubject is keyed by subject ID; subjectNames gives each ID's current name.",
+		Tags:        []string{tagProgress},
+		Parameters:  []Parameter{ifNoneMatch},
+		Responses: merge(b.errors(http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "Rating insights.", Content: jsonContent(b.schemas.ref(study.RatingInsights{})), Headers: etagHeader},
+			"304": {Description: "The insights are unchanged since the If-None-Match tag."},
+		}),
+	})
+}
+
+// Conditional request headers shared by the versioned resources.
+var (
+	ifMatch = Parameter{
+		Name:        "If-Match",
+		In:          "header",
+		Description: "ETag from a previous read; the write fails with 412 if the resource changed since.",
+		Schema:      &Schema{Type: "string"},
+	}
+	ifNoneMatch = Parameter{
+		Name:        "If-None-Match",
+		In:          "header",
+		Description: "ETag from a previous read; answers 304 when nothing changed.",
+		Schema:      &Schema{Type: "string"},
+	}
+	idempotencyKey = Parameter{
+		Name:        "Idempotency-Key",
+		In:          "header",
+		Description: "Client-chosen key of up to 255 characters; retries with the same key and body replay the original response.",
+		Schema:      &Schema{Type: "string"},
+	}
+	etagHeader = map[string]Header{
+		"ETag": {Description: "Entity tag for conditional requests.", Schema: &Schema{Type: "string"}},
+	}
+)
+
+func (b *builder) describeSync() {
+	b.add(http.MethodGet, "/sync", &Operation{
+		OperationID: "sync",
+		Summary:     "Sessions and subjects changed since a sync token",
+		Description: "Without since, returns every live item and full=true. With the token from a previous response, returns items created or updated since then plus tombstones for deleted ones. Items may repeat across syncs; keep the copy with the higher version.",
+		Tags:        []string{tagSync},
+		Parameters: []Parameter{
+			{Name: "since", In: "query", Description: "Token from the previous sync response.", Schema: &Schema{Type: "string"}},
+		},
+		Responses: merge(b.errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusGone), map[string]Response{
+			"200": {Description: "Changes and the token for the next sync.", Content: jsonContent(b.schemas.ref(study.SyncChanges{}))},
+		}),
+	})
+}
+
+func (b *builder) describeTrash() {
+	sessionRef := b.schemas.ref(study.StudySession{})
+	subjectRef := b.schemas.ref(study.Subject{})
+
+	b.add(http.MethodGet, "/trash", &Operation{
+		OperationID: "listTrash",
+		Summary:     "List deleted sessions and subjects",
+		Tags:        []string{tagTrash},
+		Responses: merge(b.errors(http.StatusUnauthorized), map[string]Response{
+			"200": {Description: "Trashed items; they are purged after purgeAfter.", Content: jsonContent(b.schemas.ref(study.Trash{}))},
+		}),
+	})
+	b.add(http.MethodPost, "/study-sessions/:id/restore", &Operation{
+		OperationID: "restoreStudySession",
+		Summary:     "Restore a deleted session, and its subject if that was deleted too",
+		Tags:        []string{tagTrash},
+		Parameters:  []Parameter{ifMatch},
+		Responses: merge(b.errors(http.StatusUnauthorized, http.StatusNotFound, http.StatusPreconditionFailed), map[string]Response{
+			"200": {Description: "The restored session.", Content: jsonContent(sessionRef), Headers: etagHeader},
+		}),
+	})
+	b.add(http.MethodPost, "/subjects/:id/restore", &Operation{
+		OperationID: "restoreSubject",
+		Summary:     "Restore a deleted subject and relink its sessions",
+		Tags:        []string{tagTrash},
+		Parameters:  []Parameter{ifMatch},
+		Responses: merge(b.errors(http.StatusUnauthorized, http.StatusNotFound, http.StatusPreconditionFailed), map[string]Response{
+			"200": {Description: "The restored subject.", Content: jsonContent(subjectRef), Headers: etagHeader},
+		}),
+	})
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+func jsonBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: jsonContent(schema)}
+}
+
+// mergePatchBody describes an RFC 7396 patch of the given resource.
+func mergePatchBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]MediaType{
+		"application/merge-patch+json": {Schema: schema},
+		"application/json":             {Schema: schema},
+	}}
+}
+
+func arrayOf(schema *Schema) *Schema {
+	return &Schema{Type: "array", Items: schema}
+}
+
+func merge(maps ...map[string]Response) map[string]Response {
+	out := make(map[string]Response)
+	for _, m := range maps {
+		for k, v := range m {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// convertPath turns "/subjects/:id" into "/subjects/{id}" plus its parameters.
+func convertPath(path string) (string, []Parameter) {
+	segments := strings.Split(path, "/")
+	var params []Parameter
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(segment, ":"), "?")
+		segments[i] = "{" + name + "}"
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	return strings.Join(segments, "/"), params
+}
@@ -0,0 +1,93 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Machine-readable codes shared across domains. Domain packages define their
+// own, more specific codes alongside their sentinel errors.
+const (
+	CodeInvalidPayload   = "invalid_payload"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeInternal         = "internal_error"
+)
+
+// Error is an API error rendered as a JSON envelope by Handler.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details []FieldError
+	// Err is the underlying cause. It is logged but never sent to clients.
+	Err error
+}
+
+// FieldError names a request field that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// New creates an API error with a client-safe message.
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Wrap attaches a cause to an API error built from status, code and message.
+func Wrap(err error, status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message, Err: err}
+}
+
+// Validation creates a 400 error listing the offending fields.
+func Validation(code, message string, fields ...FieldError) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: code, Message: message, Details: fields}
+}
+
+// InvalidPayload reports a request body that could not be decoded.
+func InvalidPayload() *Error {
+	return New(http.StatusBadRequest, CodeInvalidPayload, "invalid payload")
+}
+
+// Field is shorthand for building a FieldError.
+func Field(field, message string) FieldError {
+	return FieldError{Field: field, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Mapping translates a sentinel domain error into an API error.
+type Mapping struct {
+	Target  error
+	Status  int
+	Code    string
+	Details []FieldError
+}
+
+// Map returns the API error for the first mapping whose target matches err,
+// using the domain error's text as the message. Unmatched errors are returned
+// unchanged so the error handler reports them as internal errors.
+func Map(err error, mappings ...Mapping) error {
+	if err == nil {
+		return nil
+	}
+	for _, m := range mappings {
+		if errors.Is(err, m.Target) {
+			return &Error{Status: m.Status, Code: m.Code, Message: m.Target.Error(), Details: m.Details, Err: err}
+		}
+	}
+	return err
+}
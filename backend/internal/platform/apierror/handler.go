@@ -0,0 +1,78 @@
+package apierror
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"studytracker/internal/platform/logging"
+)
+
+// Body is the JSON envelope returned for every failed request.
+type Body struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"requestId,omitempty"`
+}
+
+// Handler is a fiber.ErrorHandler that renders errors as a Body. Unknown
+// errors become a generic 500 so driver or SQL text never reaches clients;
+// the original error is logged with the request ID instead.
+func Handler(logger *slog.Logger) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		apiErr := Normalize(err)
+		if apiErr.Status >= http.StatusInternalServerError {
+			logger.ErrorContext(c.UserContext(), "request failed",
+				"method", c.Method(), "path", c.Path(), "status", apiErr.Status, "error", err)
+		}
+
+		return c.Status(apiErr.Status).JSON(Body{
+			Code:      apiErr.Code,
+			Message:   apiErr.Message,
+			Details:   apiErr.Details,
+			RequestID: logging.RequestID(c),
+		})
+	}
+}
+
+// Normalize converts any error into an *Error the way Handler renders it.
+func Normalize(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return &Error{Status: fiberErr.Code, Code: codeForStatus(fiberErr.Code), Message: fiberErr.Message}
+	}
+
+	return &Error{
+		Status:  http.StatusInternalServerError,
+		Code:    CodeInternal,
+		Message: "internal server error",
+		Err:     err,
+	}
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidPayload
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusInternalServerError:
+		return CodeInternal
+	default:
+		// e.g. 405 becomes "method_not_allowed".
+		return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	}
+}
@@ -0,0 +1,57 @@
+package background
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Group runs long-lived goroutines that share a lifetime with the server.
+// Stop cancels them and waits for each one to return.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	logger *slog.Logger
+}
+
+// NewGroup creates an empty group ready to accept jobs.
+func NewGroup(logger *slog.Logger) *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{ctx: ctx, cancel: cancel, logger: logger}
+}
+
+// Go starts fn in its own goroutine. fn must return once ctx is cancelled.
+func (g *Group) Go(name string, fn func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn(g.ctx)
+		g.logger.Info("background job stopped", "job", name)
+	}()
+}
+
+// Every runs fn immediately and then on each tick of interval until the group stops.
+func (g *Group) Every(name string, interval time.Duration, fn func(ctx context.Context) error) {
+	g.Go(name, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := fn(ctx); err != nil && ctx.Err() == nil {
+				g.logger.Error("background job failed", "job", name, "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// Stop cancels every job and blocks until they have all returned.
+func (g *Group) Stop() {
+	g.cancel()
+	g.wg.Wait()
+}
@@ -69,21 +69,12 @@ func ApplyMigrations(ctx context.Context, db *sql.DB) error {
 		return fmt.Errorf("create schema_migrations table: %w", err)
 	}
 
-	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	names, err := migrationNames()
 	if err != nil {
-		return fmt.Errorf("read migrations: %w", err)
+		return err
 	}
 
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].Name() < entries[j].Name()
-	})
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-		name := entry.Name()
-
+	for _, name := range names {
 		var count int
 		query := Rebind("SELECT COUNT(1) FROM schema_migrations WHERE name = ?", useDollar)
 		if err := db.QueryRowContext(ctx, query, name).Scan(&count); err != nil {
@@ -122,6 +113,81 @@ func ApplyMigrations(ctx context.Context, db *sql.DB) error {
 	return nil
 }
 
+// PendingMigrations lists embedded migrations that have not been recorded in
+// schema_migrations, in the order they would be applied.
+func PendingMigrations(ctx context.Context, db *sql.DB) ([]string, error) {
+	names, err := migrationNames()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT name FROM schema_migrations;")
+	if err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		applied[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []string
+	for _, name := range names {
+		if !applied[name] {
+			pending = append(pending, name)
+		}
+	}
+	return pending, nil
+}
+
+// LatestMigration returns the name of the most recent migration recorded in
+// schema_migrations, or "" when none has been applied.
+func LatestMigration(ctx context.Context, db *sql.DB) (string, error) {
+	var name sql.NullString
+	if err := db.QueryRowContext(ctx, "SELECT MAX(name) FROM schema_migrations;").Scan(&name); err != nil {
+		return "", fmt.Errorf("read latest migration: %w", err)
+	}
+	return name.String, nil
+}
+
+func migrationNames() ([]string, error) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Checkpoint folds SQLite's write-ahead log back into the main database file so
+// nothing is left in the -wal sidecar once the connection closes. It is a
+// no-op for other drivers.
+func Checkpoint(ctx context.Context, db *sql.DB) error {
+	if db == nil || UsesDollarPlaceholders(db) {
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE);"); err != nil {
+		return fmt.Errorf("checkpoint wal: %w", err)
+	}
+	return nil
+}
+
 func ensureDirectory(dsn string) error {
 	if strings.HasPrefix(dsn, "file:") {
 		path := strings.TrimPrefix(dsn, "file:")
@@ -156,6 +222,14 @@ func detectDriver(dsn string) string {
 	}
 }
 
+// Executor is the query surface shared by *sql.DB and *sql.Tx, so repositories
+// can run either directly or inside a transaction.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 // UsesDollarPlaceholders reports whether the active SQL driver expects $1-style placeholders.
 func UsesDollarPlaceholders(db *sql.DB) bool {
 	driverName := fmt.Sprintf("%T", db.Driver())
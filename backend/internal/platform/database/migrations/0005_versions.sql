@@ -0,0 +1,5 @@
+ALTER TABLE study_sessions
+ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE subjects
+ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+ALTER TABLE study_sessions ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE subjects ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_study_sessions_user_updated_at ON study_sessions (user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_subjects_user_updated_at ON subjects (user_id, updated_at);
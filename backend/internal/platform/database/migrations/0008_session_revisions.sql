@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS session_revisions (
+    id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    actor_id TEXT NOT NULL,
+    revision INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    before_snapshot TEXT,
+    after_snapshot TEXT,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_revisions_session ON session_revisions (session_id, revision);
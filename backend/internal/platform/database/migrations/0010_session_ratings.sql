@@ -0,0 +1,4 @@
+ALTER TABLE study_sessions ADD COLUMN focus INTEGER;
+ALTER TABLE study_sessions ADD COLUMN mood INTEGER;
+ALTER TABLE study_sessions ADD COLUMN energy INTEGER;
+ALTER TABLE study_sessions ADD COLUMN difficulty INTEGER;
@@ -0,0 +1,23 @@
+ALTER TABLE study_sessions ADD COLUMN mode TEXT NOT NULL DEFAULT 'manual';
+
+CREATE TABLE IF NOT EXISTS session_segments (
+    session_id TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL,
+    planned_minutes INTEGER NOT NULL DEFAULT 0,
+    interrupted BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (session_id, position),
+    FOREIGN KEY (session_id) REFERENCES study_sessions(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS pomodoro_settings (
+    user_id TEXT PRIMARY KEY,
+    work_minutes INTEGER NOT NULL,
+    short_break_minutes INTEGER NOT NULL,
+    long_break_minutes INTEGER NOT NULL,
+    long_break_every INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+CREATE INDEX IF NOT EXISTS idx_study_sessions_user_range ON study_sessions (user_id, start_time, end_time);
+
+CREATE TABLE IF NOT EXISTS study_preferences (
+    user_id TEXT PRIMARY KEY,
+    overlap_policy TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+ALTER TABLE subjects ADD COLUMN parent_id TEXT REFERENCES subjects(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subjects_parent_id ON subjects (parent_id);
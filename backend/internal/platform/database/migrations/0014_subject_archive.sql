@@ -0,0 +1 @@
+ALTER TABLE subjects ADD COLUMN archived_at TIMESTAMP;
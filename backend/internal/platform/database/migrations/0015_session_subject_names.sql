@@ -0,0 +1,6 @@
+UPDATE study_sessions
+SET subject_name = (SELECT s.name FROM subjects s WHERE s.id = study_sessions.subject_id)
+WHERE EXISTS (
+    SELECT 1 FROM subjects s
+    WHERE s.id = study_sessions.subject_id AND s.name <> study_sessions.subject_name
+);
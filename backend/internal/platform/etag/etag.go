@@ -0,0 +1,81 @@
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ForVersion returns the strong ETag for a resource at the given version.
+func ForVersion(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// SetVersion sets the ETag response header for a versioned resource.
+func SetVersion(c *fiber.Ctx, version int) {
+	c.Set(fiber.HeaderETag, ForVersion(version))
+}
+
+// IfMatch reads the If-Match precondition. It returns 0 when the header is
+// absent or "*", meaning the write is unconditional. A tag that is not a
+// version ETag yields -1, which never matches a stored version, so the
+// request fails with 412 as RFC 9110 requires.
+func IfMatch(c *fiber.Ctx) int {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return 0
+	}
+	// Only the first tag is considered; clients send the one they last read.
+	tag, _, _ := strings.Cut(header, ",")
+	tag = strings.TrimSpace(tag)
+	if strings.HasPrefix(tag, "W/") {
+		// Weak tags never satisfy If-Match.
+		return -1
+	}
+	version, err := strconv.Atoi(strings.Trim(tag, `"`))
+	if err != nil || version <= 0 {
+		return -1
+	}
+	return version
+}
+
+// JSON encodes v, tags the response with a weak ETag derived from the body
+// and answers 304 Not Modified when the client's If-None-Match already
+// matches. It suits collection and summary endpoints that have no single
+// version number.
+func JSON(c *fiber.Ctx, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(body)
+	tag := `W/"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+
+	c.Set(fiber.HeaderETag, tag)
+	if matchesNoneMatch(c.Get(fiber.HeaderIfNoneMatch), tag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
+
+// matchesNoneMatch applies weak comparison, as If-None-Match requires.
+func matchesNoneMatch(header, tag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	opaque := strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package etag
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{header: "", want: 0},
+		{header: "*", want: 0},
+		{header: `"3"`, want: 3},
+		{header: ` "3" `, want: 3},
+		{header: `"3", "4"`, want: 3},
+		{header: `W/"3"`, want: -1},
+		{header: `"abc"`, want: -1},
+		{header: `"0"`, want: -1},
+		{header: `"-2"`, want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			app := fiber.New()
+			var got int
+			app.Get("/", func(c *fiber.Ctx) error {
+				got = IfMatch(c)
+				return nil
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderIfMatch, tt.header)
+			}
+			if _, err := app.Test(req); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("IfMatch(%q) = %d, want %d", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONAnswersNotModified(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		return JSON(c, map[string]int{"total": 3})
+	})
+
+	first, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag := first.Header.Get(fiber.HeaderETag)
+	if first.StatusCode != fiber.StatusOK || tag == "" {
+		t.Fatalf("first response: %d with ETag %q", first.StatusCode, tag)
+	}
+
+	for _, header := range []string{tag, tag[2:], `"other", ` + tag, "*"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderIfNoneMatch, header)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusNotModified {
+			t.Errorf("If-None-Match %s: status %d, want 304", header, resp.StatusCode)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderIfNoneMatch, `W/"stale"`)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK || string(body) != `{"total":3}` {
+		t.Fatalf("stale tag: %d %s, want 200 with the body", resp.StatusCode, body)
+	}
+}
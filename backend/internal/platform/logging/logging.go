@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Options controls how log records are rendered.
+type Options struct {
+	Level        string
+	Format       string
+	RedactEmails bool
+}
+
+// New builds a logger writing to w. Records logged with a context carrying a
+// request ID automatically include it as the "requestId" attribute.
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	if opts.RedactEmails {
+		handlerOpts.ReplaceAttr = redactEmailAttr
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(w, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want text or json)", opts.Format)
+	}
+
+	return slog.New(contextHandler{Handler: handler}), nil
+}
+
+// ParseLevel converts debug, info, warn or error into a slog level.
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if value == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", value)
+	}
+	return level, nil
+}
+
+// Discard returns a logger that drops every record, for callers that were not given one.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// contextHandler copies the request ID from the record's context onto the record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("requestId", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// redactEmailAttr masks any attribute named "email" so addresses never reach
+// log storage in full: "jane.doe@example.com" becomes "j***@example.com".
+func redactEmailAttr(_ []string, attr slog.Attr) slog.Attr {
+	if attr.Key != "email" || attr.Value.Kind() != slog.KindString {
+		return attr
+	}
+	return slog.String(attr.Key, RedactEmail(attr.Value.String()))
+}
+
+// RedactEmail keeps the first character of the local part and the domain.
+func RedactEmail(email string) string {
+	local, domain, found := strings.Cut(email, "@")
+	if !found || local == "" {
+		return "***"
+	}
+	return local[:1] + "***@" + domain
+}
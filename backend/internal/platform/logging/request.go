@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID on both requests and responses.
+const RequestIDHeader = "X-Request-ID"
+
+// ContextRequestIDKey stores the request ID in Fiber's locals.
+const ContextRequestIDKey = "requestID"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID returns the ID assigned to the current request by Middleware.
+func RequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(ContextRequestIDKey).(string)
+	return id
+}
+
+// Middleware assigns every request an ID, echoes it in the response header,
+// exposes it to handlers through c.UserContext() and writes an access log line
+// once the request completes. A well-formed incoming X-Request-ID is reused so
+// IDs can be correlated across proxies.
+func Middleware(logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		id := c.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		} else {
+			id = utils.CopyString(id)
+		}
+		c.Locals(ContextRequestIDKey, id)
+		c.Set(RequestIDHeader, id)
+		ctx := WithRequestID(c.UserContext(), id)
+		c.SetUserContext(ctx)
+
+		method := utils.CopyString(c.Method())
+		path := utils.CopyString(c.Path())
+		err := c.Next()
+		if err != nil {
+			// Render the error now so the logged status matches the response.
+			err = c.App().ErrorHandler(c, err)
+		}
+
+		status := c.Response().StatusCode()
+
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.Log(ctx, level, "http request",
+			slog.String("method", method),
+			slog.String("path", path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+		)
+		return err
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+// Package mergepatch implements JSON Merge Patch (RFC 7396).
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ContentType is the media type registered for merge patch documents.
+const ContentType = "application/merge-patch+json"
+
+// ErrInvalidPatch is returned when the patch document is not valid JSON.
+var ErrInvalidPatch = errors.New("invalid merge patch document")
+
+// Apply merges patch into the JSON document doc and returns the result.
+// Members set to null in the patch are removed; objects merge recursively
+// and every other value replaces the original.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var patchValue any
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, ErrInvalidPatch
+	}
+	var target any
+	if len(doc) > 0 {
+		if err := json.Unmarshal(doc, &target); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(merge(target, patchValue))
+}
+
+func merge(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any, len(patchObject))
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+	return targetObject
+}
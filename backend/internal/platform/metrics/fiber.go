@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedRoute labels requests that fell through to the SPA or 404 handler,
+// keeping label cardinality bounded regardless of the paths clients request.
+const unmatchedRoute = "unmatched"
+
+// Middleware records request counts and latency keyed by the matched route
+// pattern (for example /api/study-sessions/:id) rather than the raw path.
+func (m *Metrics) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		// fasthttp reuses request buffers, so copy the method before it is recycled.
+		method := utils.CopyString(c.Method())
+		err := c.Next()
+		if err != nil {
+			// Render the error now so the recorded status matches the response.
+			err = c.App().ErrorHandler(c, err)
+		}
+
+		status := c.Response().StatusCode()
+
+		route := unmatchedRoute
+		if r := c.Route(); r != nil && r.Path != "/" && r.Path != "/*" {
+			route = r.Path
+		}
+		m.observeRequest(method, route, status, time.Since(start))
+		return err
+	}
+}
+
+// Handler serves the registry in Prometheus text format. When token is set,
+// scrapers must send it as a bearer token. Without one, loopback clients are
+// only let in when allowLoopback is set; every other request is refused so
+// the endpoint is never public by accident.
+func (m *Metrics) Handler(token string, allowLoopback bool) fiber.Handler {
+	serve := adaptor.HTTPHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	return func(c *fiber.Ctx) error {
+		if token != "" {
+			expected := "Bearer " + token
+			got := c.Get(fiber.HeaderAuthorization)
+			if subtle.ConstantTimeCompare([]byte(got), []byte(expected)) != 1 {
+				return fiber.ErrUnauthorized
+			}
+		} else if !allowLoopback || !c.Context().RemoteIP().IsLoopback() {
+			return fiber.ErrForbidden
+		}
+		return serve(c)
+	}
+}
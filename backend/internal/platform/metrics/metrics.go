@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"studytracker/internal/platform/database"
+)
+
+const namespace = "studytracker"
+
+// Metrics owns the Prometheus registry and every collector the server exposes.
+// A nil *Metrics is valid and records nothing, so callers never need to check
+// whether metrics are enabled.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	sessionsLogged prometheus.Counter
+	minutesLogged  prometheus.Counter
+	registrations  prometheus.Counter
+	logins         prometheus.Counter
+	loginFailures  prometheus.Counter
+}
+
+// New registers HTTP, database and domain collectors against a fresh registry.
+func New(db *sql.DB) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by method, matched route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method, matched route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		sessionsLogged: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "study",
+			Name:      "sessions_logged_total",
+			Help:      "Study sessions created.",
+		}),
+		minutesLogged: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "study",
+			Name:      "minutes_logged_total",
+			Help:      "Study minutes recorded by newly created sessions.",
+		}),
+		registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "registrations_total",
+			Help:      "Successful account registrations.",
+		}),
+		logins: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "logins_total",
+			Help:      "Successful password logins.",
+		}),
+		loginFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "login_failures_total",
+			Help:      "Rejected password logins.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, namespace),
+		m.httpRequests,
+		m.httpDuration,
+		m.sessionsLogged,
+		m.minutesLogged,
+		m.registrations,
+		m.logins,
+		m.loginFailures,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "schema",
+			Name:      "migration_version",
+			Help:      "Numeric prefix of the latest applied migration.",
+		}, migrationVersion(db)),
+	)
+
+	return m
+}
+
+// SessionLogged records a newly created study session.
+func (m *Metrics) SessionLogged(minutes int) {
+	if m == nil {
+		return
+	}
+	m.sessionsLogged.Inc()
+	m.minutesLogged.Add(float64(minutes))
+}
+
+// Registered records a successful registration.
+func (m *Metrics) Registered() {
+	if m == nil {
+		return
+	}
+	m.registrations.Inc()
+}
+
+// LoggedIn records a successful login.
+func (m *Metrics) LoggedIn() {
+	if m == nil {
+		return
+	}
+	m.logins.Inc()
+}
+
+// LoginFailed records a rejected login attempt.
+func (m *Metrics) LoginFailed() {
+	if m == nil {
+		return
+	}
+	m.loginFailures.Inc()
+}
+
+func (m *Metrics) observeRequest(method, route string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, code).Inc()
+	m.httpDuration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
+
+func migrationVersion(db *sql.DB) func() float64 {
+	return func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		name, err := database.LatestMigration(ctx, db)
+		if err != nil || name == "" {
+			return 0
+		}
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return 0
+		}
+		return float64(version)
+	}
+}
@@ -2,7 +2,7 @@ package router
 
 import (
 	"context"
-	"os"
+	"log/slog"
 	"path/filepath"
 	"strings"
 	"time"
@@ -10,23 +10,42 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	"studytracker/internal/auth"
+	"studytracker/internal/config"
+	"studytracker/internal/health"
+	"studytracker/internal/idempotency"
+	"studytracker/internal/openapi"
+	"studytracker/internal/platform/apierror"
+	"studytracker/internal/platform/background"
 	"studytracker/internal/platform/database"
+	"studytracker/internal/platform/logging"
+	"studytracker/internal/platform/metrics"
 	"studytracker/internal/study"
 	"studytracker/internal/user"
 )
 
-// New wires the Fiber application for the API and static frontend.
-func New(dsn string) (*fiber.App, error) {
-	app := fiber.New()
+const (
+	idleTimeout              = 60 * time.Second
+	sessionPurgeInterval     = time.Hour
+	idempotencyPurgeInterval = time.Hour
+	trashPurgeInterval       = time.Hour
+)
 
-	// Lightweight health endpoint used by deploy targets to verify liveness.
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.SendString("ok")
+// New wires the Fiber application for the API and static frontend.
+func New(cfg config.Config, logger *slog.Logger) (*fiber.App, error) {
+	app := fiber.New(fiber.Config{
+		// Idle keep-alive connections would otherwise hold graceful shutdown
+		// open until the drain deadline.
+		IdleTimeout: idleTimeout,
+		// Errors are rendered as a JSON envelope with a machine-readable code.
+		ErrorHandler: apierror.Handler(logger),
 	})
 
+	// Every request gets an ID that is echoed in X-Request-ID and attached to its log lines.
+	app.Use(logging.Middleware(logger))
+
 	// Open a SQLite (or configured) connection and run migrations so the schema
 	// is always in sync before the server starts handling traffic.
-	db, err := database.Open(database.Config{DSN: dsn})
+	db, err := database.Open(database.Config{DSN: cfg.Database.URL})
 	if err != nil {
 		return nil, err
 	}
@@ -36,33 +55,103 @@ func New(dsn string) (*fiber.App, error) {
 		return nil, err
 	}
 
+	// Metrics are opt-in; a nil *metrics.Metrics records nothing.
+	var appMetrics *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		appMetrics = metrics.New(db)
+		app.Use(appMetrics.Middleware())
+		app.Get("/metrics", appMetrics.Handler(cfg.Metrics.Token, cfg.Metrics.AllowLoopback))
+	}
+
+	// Liveness only needs the process; readiness also checks the database and schema.
+	checker := health.NewChecker(cfg.Health.CheckTimeout, cfg.Health.CacheTTL)
+	checker.Register("database", health.DatabasePing(db))
+	checker.Register("migrations", health.Migrations(db))
+	health.NewHandler(checker).RegisterRoutes(app)
+
 	// Repositories wrap SQL access, while the service layer enforces business rules.
-	sessionRepo := study.NewSQLSessionRepository(db)
-	subjectRepo := study.NewSQLSubjectRepository(db)
 	userRepo := user.NewSQLRepository(db)
 	sessionStore := auth.NewSQLSessionStore(db)
-	sessionTTL := parseDuration(getenv("SESSION_TTL", "24h"), 24*time.Hour)
+	idempotencyStore := idempotency.NewSQLStore(db)
 
 	authService := auth.NewService(userRepo, sessionStore, auth.Config{
-		SessionTTL:         sessionTTL,
-		GoogleClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-		GoogleClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
-		GoogleRedirectURL:  os.Getenv("GOOGLE_REDIRECT_URL"),
-	})
-	authHandler := auth.NewHandler(authService, getenv("FRONTEND_URL", ""), os.Getenv("GOOGLE_REDIRECT_URL"))
-	authMiddleware := auth.NewMiddleware(sessionStore)
+		SessionTTL:         cfg.Auth.SessionTTL,
+		GoogleClientID:     cfg.Auth.Google.ClientID,
+		GoogleClientSecret: cfg.Auth.Google.ClientSecret,
+		GoogleRedirectURL:  cfg.Auth.Google.RedirectURL,
+	}, appMetrics, logger)
+	authHandler := auth.NewHandler(authService, cfg.Server.FrontendURL, cfg.Auth.Google.RedirectURL)
+	authMiddleware := auth.NewMiddleware(sessionStore, logger)
+
+	service := study.NewService(study.Repositories{
+		Sessions:    study.NewSQLSessionRepository(db),
+		Subjects:    study.NewSQLSubjectRepository(db, logger),
+		Revisions:   study.NewSQLRevisionRepository(db),
+		Tags:        study.NewSQLTagRepository(db),
+		Pomodoro:    study.NewSQLPomodoroSettingsRepository(db),
+		Preferences: study.NewSQLPreferencesRepository(db),
+	}, study.NewSQLTransactor(db, logger), study.Config{
+		TrashRetention: cfg.API.TrashRetention,
+	}, appMetrics, logger)
+	handler := study.NewHandler(service, cfg.API.BatchMaxSize, logger)
+	idempotent := idempotency.NewMiddleware(idempotencyStore, cfg.API.IdempotencyTTL, logger)
+
+	// The OpenAPI document is built from the same types the handlers encode.
+	v1 := apiVersion{name: "v1"}
+	apiDoc := openapi.Build(v1.prefix())
+	docsHandler, err := openapi.NewHandler(apiDoc)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	v1.mount = func(r fiber.Router) {
+		authHandler.RegisterRoutes(r.Group("/auth"), authMiddleware.RequireAuth)
+		handler.RegisterRoutes(r, authMiddleware.RequireAuth, idempotent.Handler)
+		docsHandler.RegisterRoutes(r)
+	}
+	// /api stays as a deprecated alias of v1 until the configured sunset date.
+	mountAPI(app, []apiVersion{v1}, v1.name, cfg.Server.LegacyAPISunset)
 
-	service := study.NewService(sessionRepo, subjectRepo)
-	handler := study.NewHandler(service)
+	// Any API route the document does not describe is reported at startup.
+	if missing := undocumentedRoutes(app, apiDoc, v1); len(missing) > 0 {
+		logger.Warn("routes missing from OpenAPI document", "routes", missing)
+	}
 
-	publicAPI := app.Group("/api")
-	authGroup := publicAPI.Group("/auth")
-	authHandler.RegisterRoutes(authGroup, authMiddleware.RequireAuth)
+	// Background jobs share the server's lifetime and are stopped on shutdown.
+	jobs := background.NewGroup(logger)
+	jobs.Every("auth-session-purge", sessionPurgeInterval, func(ctx context.Context) error {
+		purged, err := sessionStore.DeleteExpired(time.Now())
+		if err == nil && purged > 0 {
+			logger.Info("purged expired login sessions", "count", purged)
+		}
+		return err
+	})
+	jobs.Every("idempotency-key-purge", idempotencyPurgeInterval, func(ctx context.Context) error {
+		purged, err := idempotencyStore.DeleteExpired(time.Now())
+		if err == nil && purged > 0 {
+			logger.Info("purged expired idempotency keys", "count", purged)
+		}
+		return err
+	})
 
-	handler.RegisterRoutes(publicAPI, authMiddleware.RequireAuth)
+	jobs.Every("trash-purge", trashPurgeInterval, func(ctx context.Context) error {
+		sessions, subjects, err := service.PurgeTrash(ctx, time.Now())
+		if err == nil && sessions+subjects > 0 {
+			logger.Info("purged trash", "sessions", sessions, "subjects", subjects)
+		}
+		return err
+	})
 
-	// Make sure the database connection is closed when Fiber shuts down.
+	// Once Fiber has drained in-flight requests, stop background work, flush
+	// SQLite's WAL and only then close the database connection.
 	app.Hooks().OnShutdown(func() error {
+		jobs.Stop()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := database.Checkpoint(ctx, db); err != nil {
+			logger.Error("shutdown checkpoint failed", "error", err)
+		}
 		return db.Close()
 	})
 
@@ -70,6 +159,7 @@ func New(dsn string) (*fiber.App, error) {
 	// needing a second process.
 	frontendDir, err := filepath.Abs("../frontend")
 	if err != nil {
+		jobs.Stop()
 		db.Close()
 		return nil, err
 	}
@@ -93,18 +183,8 @@ func New(dsn string) (*fiber.App, error) {
 	return app, nil
 }
 
-func getenv(key, fallback string) string {
-	// Mirrors os.Getenv but allows us to inject sane defaults for local development.
-	if value, ok := os.LookupEnv(key); ok && value != "" {
-		return value
-	}
-	return fallback
-}
-
-func parseDuration(value string, fallback time.Duration) time.Duration {
-	// Time parsing can fail if misconfigured; fall back to a safe default.
-	if d, err := time.ParseDuration(value); err == nil {
-		return d
-	}
-	return fallback
+// undocumentedRoutes lists the version's routes that apiDoc does not
+// describe, apart from the document and docs page themselves.
+func undocumentedRoutes(app *fiber.App, apiDoc openapi.Document, v apiVersion) []string {
+	return openapi.Undocumented(apiDoc, app.GetRoutes(true), v.prefix(), openapi.SpecPath, openapi.DocsPath)
 }
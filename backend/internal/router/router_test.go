@@ -0,0 +1,43 @@
+package router
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"studytracker/internal/config"
+	"studytracker/internal/openapi"
+)
+
+// newTestApp builds the full application against a fresh SQLite database.
+func newTestApp(t *testing.T) (*fiber.App, config.Config) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Database.URL = "file:" + filepath.Join(t.TempDir(), "test.db") + "?_pragma=foreign_keys(ON)"
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	app, err := New(cfg, logger)
+	if err != nil {
+		t.Fatalf("build app: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := app.Shutdown(); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	})
+	return app, cfg
+}
+
+// TestEveryRouteIsDocumented fails when a route is registered under /api/v1
+// without a matching operation in the OpenAPI document.
+func TestEveryRouteIsDocumented(t *testing.T) {
+	app, _ := newTestApp(t)
+	v1 := apiVersion{name: "v1"}
+
+	if missing := undocumentedRoutes(app, openapi.Build(v1.prefix()), v1); len(missing) > 0 {
+		t.Fatalf("routes missing from the OpenAPI document:\n%v", missing)
+	}
+}
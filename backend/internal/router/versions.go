@@ -0,0 +1,62 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const apiPrefix = "/api"
+
+// apiVersion is one generation of the public API, mounted at /api/<name>.
+// Versions share the same services, so a future v2 can change request and
+// response shapes with its own handlers without duplicating business logic.
+type apiVersion struct {
+	name  string
+	mount func(r fiber.Router)
+}
+
+func (v apiVersion) prefix() string {
+	return apiPrefix + "/" + v.name
+}
+
+// mountAPI mounts every version under its prefix and re-mounts the alias
+// version directly under /api for clients that predate versioning. Responses
+// served through the unversioned alias carry Deprecation, Sunset and a Link to
+// the successor version.
+func mountAPI(app *fiber.App, versions []apiVersion, alias string, sunset time.Time) {
+	for _, v := range versions {
+		v.mount(app.Group(v.prefix()))
+	}
+
+	for _, v := range versions {
+		if v.name != alias {
+			continue
+		}
+		legacy := app.Group(apiPrefix, deprecated(versions, v, sunset))
+		v.mount(legacy)
+	}
+}
+
+// deprecated marks responses from the unversioned alias. The group middleware
+// also matches versioned paths, so those are passed through untouched.
+func deprecated(versions []apiVersion, successor apiVersion, sunset time.Time) fiber.Handler {
+	sunsetValue := sunset.UTC().Format(http.TimeFormat)
+	link := "<" + successor.prefix() + ">; rel=\"successor-version\""
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		for _, v := range versions {
+			if path == v.prefix() || strings.HasPrefix(path, v.prefix()+"/") {
+				return c.Next()
+			}
+		}
+		c.Set("Deprecation", "true")
+		if !sunset.IsZero() {
+			c.Set("Sunset", sunsetValue)
+		}
+		c.Append(fiber.HeaderLink, link)
+		return c.Next()
+	}
+}
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestMountAPIMountsVersionsAndDeprecatedAlias(t *testing.T) {
+	sunset := time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)
+	app := fiber.New()
+	v1 := apiVersion{name: "v1", mount: func(r fiber.Router) {
+		r.Get("/ping", func(c *fiber.Ctx) error { return c.SendString("pong") })
+	}}
+	mountAPI(app, []apiVersion{v1}, v1.name, sunset)
+
+	tests := []struct {
+		path       string
+		status     int
+		deprecated bool
+	}{
+		{path: "/api/v1/ping", status: fiber.StatusOK},
+		{path: "/api/ping", status: fiber.StatusOK, deprecated: true},
+		{path: "/api/v2/ping", status: fiber.StatusNotFound, deprecated: true},
+		{path: "/ping", status: fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp := do(t, app, http.MethodGet, tt.path)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			checkDeprecation(t, resp, tt.deprecated, "Wed, 30 Jun 2027 00:00:00 GMT")
+		})
+	}
+}
+
+func TestMountAPIWithoutSunsetOmitsSunsetHeader(t *testing.T) {
+	app := fiber.New()
+	v1 := apiVersion{name: "v1", mount: func(r fiber.Router) {
+		r.Get("/ping", func(c *fiber.Ctx) error { return c.SendString("pong") })
+	}}
+	mountAPI(app, []apiVersion{v1}, v1.name, time.Time{})
+
+	resp := do(t, app, http.MethodGet, "/api/ping")
+	if got := resp.Header.Get("Deprecation"); got != "true" {
+		t.Fatalf("Deprecation = %q, want true", got)
+	}
+	if got := resp.Header.Get("Sunset"); got != "" {
+		t.Fatalf("Sunset = %q, want none", got)
+	}
+}
+
+// TestAPIRoutesUnderV1AndLegacyAlias checks the real application: every API
+// route answers under /api/v1 and, with deprecation headers, under /api.
+func TestAPIRoutesUnderV1AndLegacyAlias(t *testing.T) {
+	app, cfg := newTestApp(t)
+	sunset := cfg.Server.LegacyAPISunset.UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		method     string
+		path       string
+		status     int
+		deprecated bool
+	}{
+		{method: http.MethodGet, path: "/api/v1/openapi.json", status: fiber.StatusOK},
+		{method: http.MethodGet, path: "/api/openapi.json", status: fiber.StatusOK, deprecated: true},
+		{method: http.MethodGet, path: "/api/v1/subjects", status: fiber.StatusUnauthorized},
+		{method: http.MethodGet, path: "/api/subjects", status: fiber.StatusUnauthorized, deprecated: true},
+		{method: http.MethodGet, path: "/api/v1/auth/me", status: fiber.StatusUnauthorized},
+		{method: http.MethodGet, path: "/api/auth/me", status: fiber.StatusUnauthorized, deprecated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			resp := do(t, app, tt.method, tt.path)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			checkDeprecation(t, resp, tt.deprecated, sunset)
+		})
+	}
+}
+
+func do(t *testing.T, app *fiber.App, method, path string) *http.Response {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(method, path, nil))
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func checkDeprecation(t *testing.T, resp *http.Response, deprecated bool, sunset string) {
+	t.Helper()
+	headers := map[string]string{
+		"Deprecation": "true",
+		"Sunset":      sunset,
+		"Link":        `</api/v1>; rel="successor-version"`,
+	}
+	for name, want := range headers {
+		got := resp.Header.Get(name)
+		if !deprecated {
+			want = ""
+		}
+		if got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package study
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// Batch operation kinds.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchRequest groups session operations that run in one transaction. When
+// Atomic is set, any failure rolls back every operation; otherwise each
+// operation succeeds or fails on its own.
+type BatchRequest struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation is one create, update or delete. Create takes a full
+// session in Data; update applies Data as a JSON Merge Patch. Version, when
+// non-zero, makes update and delete conditional like If-Match.
+type BatchOperation struct {
+	Op      string          `json:"op"`
+	ID      string          `json:"id,omitempty"`
+	Version int             `json:"version,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// BatchItemResult reports the outcome of one operation. Session is set for
+// successful creates and updates.
+type BatchItemResult struct {
+	Index   int
+	Op      string
+	ID      string
+	Session *StudySession
+	Err     error
+}
+
+// BatchSessions applies the operations in order, validating each through the
+// same rules as the single-item endpoints. It returns one result per
+// operation and whether the transaction committed.
+func (s *Service) BatchSessions(ctx context.Context, userID string, req BatchRequest) ([]BatchItemResult, bool, error) {
+	if s.tx == nil {
+		return nil, false, errors.New("transactor not configured")
+	}
+	if len(req.Operations) == 0 {
+		return nil, false, ErrBatchEmpty
+	}
+
+	results := make([]BatchItemResult, len(req.Operations))
+	for i, op := range req.Operations {
+		results[i] = BatchItemResult{Index: i, Op: op.Op, ID: op.ID}
+	}
+
+	err := s.tx.InTx(ctx, func(tx TxRepositories) error {
+		scoped := s.scoped(tx)
+
+		for i, op := range req.Operations {
+			if req.Atomic {
+				if err := scoped.applyBatchOperation(ctx, userID, op, &results[i]); err != nil {
+					results[i].Err = err
+					return ErrBatchAborted
+				}
+				continue
+			}
+			results[i].Err = tx.Savepoint(ctx, func() error {
+				return scoped.applyBatchOperation(ctx, userID, op, &results[i])
+			})
+		}
+		return nil
+	})
+	if errors.Is(err, ErrBatchAborted) {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = ErrBatchAborted
+				results[i].ID = req.Operations[i].ID
+				results[i].Session = nil
+			}
+		}
+		s.logger.InfoContext(ctx, "atomic session batch rolled back", "userId", userID, "operations", len(req.Operations))
+		return results, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, result := range results {
+		if result.Err == nil && result.Op == BatchCreate && result.Session != nil {
+			s.recorder.SessionLogged(result.Session.DurationMinutes)
+		}
+	}
+	s.logger.InfoContext(ctx, "session batch applied", "userId", userID, "operations", len(req.Operations), "atomic", req.Atomic)
+	return results, true, nil
+}
+
+// scoped returns a copy of the service bound to tx's repositories. Domain
+// events are not recorded from it; callers record them after commit.
+func (s *Service) scoped(tx TxRepositories) *Service {
+	scoped := &Service{
+		inTx:           true,
+		trashRetention: s.trashRetention,
+		recorder:       nopRecorder{},
+		logger:         s.logger,
+	}
+	scoped.bind(tx.Repositories)
+	return scoped
+}
+
+func (s *Service) applyBatchOperation(ctx context.Context, userID string, op BatchOperation, result *BatchItemResult) error {
+	switch op.Op {
+	case BatchCreate:
+		var session StudySession
+		if err := json.Unmarshal(op.Data, &session); err != nil {
+			return ErrInvalidBatchOperation
+		}
+		created, err := s.CreateSession(ctx, userID, session)
+		if err != nil {
+			return err
+		}
+		result.ID = created.ID
+		result.Session = &created
+	case BatchUpdate:
+		if op.ID == "" || len(op.Data) == 0 {
+			return ErrInvalidBatchOperation
+		}
+		updated, err := s.PatchSession(ctx, userID, op.ID, op.Data, op.Version)
+		if err != nil {
+			return err
+		}
+		result.Session = &updated
+	case BatchDelete:
+		if op.ID == "" {
+			return ErrInvalidBatchOperation
+		}
+		return s.DeleteSession(ctx, userID, op.ID, op.Version)
+	default:
+		return ErrInvalidBatchOperation
+	}
+	return nil
+}
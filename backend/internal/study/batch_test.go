@@ -0,0 +1,109 @@
+package study
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestBatchSessions(t *testing.T) {
+	create := func(subject string, startHour int) BatchOperation {
+		data, _ := json.Marshal(StudySession{Subject: subject, StartTime: at(startHour, 0), EndTime: at(startHour, 45)})
+		return BatchOperation{Op: BatchCreate, Data: data}
+	}
+	invalid := BatchOperation{Op: BatchCreate, Data: json.RawMessage(`{"subject":"Math"}`)}
+
+	tests := []struct {
+		name          string
+		atomic        bool
+		ops           func(existing StudySession) []BatchOperation
+		wantCommitted bool
+		wantErrs      []error
+		wantSessions  int
+	}{
+		{
+			name:   "atomic applies every operation",
+			atomic: true,
+			ops: func(existing StudySession) []BatchOperation {
+				return []BatchOperation{
+					create("Math", 10),
+					{Op: BatchUpdate, ID: existing.ID, Version: existing.Version, Data: json.RawMessage(`{"notes":"reviewed"}`)},
+				}
+			},
+			wantCommitted: true,
+			wantErrs:      []error{nil, nil},
+			wantSessions:  2,
+		},
+		{
+			name:   "atomic rolls back on the first failure",
+			atomic: true,
+			ops: func(existing StudySession) []BatchOperation {
+				return []BatchOperation{
+					create("Math", 10),
+					{Op: BatchDelete, ID: existing.ID},
+					invalid,
+					create("Math", 12),
+				}
+			},
+			wantErrs:     []error{ErrBatchAborted, ErrBatchAborted, ErrInvalidTiming, ErrBatchAborted},
+			wantSessions: 1,
+		},
+		{
+			name: "best effort keeps the operations that succeed",
+			ops: func(existing StudySession) []BatchOperation {
+				return []BatchOperation{
+					create("Math", 10),
+					invalid,
+					{Op: BatchUpdate, ID: existing.ID, Version: existing.Version + 1, Data: json.RawMessage(`{"notes":"stale"}`)},
+					{Op: BatchDelete, ID: "missing"},
+					{Op: "upsert"},
+				}
+			},
+			wantCommitted: true,
+			wantErrs:      []error{nil, ErrInvalidTiming, ErrVersionConflict, ErrNotFound, ErrInvalidBatchOperation},
+			wantSessions:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			ctx := context.Background()
+			existing := mustCreateSession(t, svc, "Physics", at(8, 0), at(9, 0))
+
+			results, committed, err := svc.BatchSessions(ctx, testUser, BatchRequest{Atomic: tt.atomic, Operations: tt.ops(existing)})
+			if err != nil {
+				t.Fatalf("BatchSessions: %v", err)
+			}
+			if committed != tt.wantCommitted {
+				t.Fatalf("committed = %v, want %v", committed, tt.wantCommitted)
+			}
+			if len(results) != len(tt.wantErrs) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.wantErrs))
+			}
+			for i, want := range tt.wantErrs {
+				if !errors.Is(results[i].Err, want) {
+					t.Errorf("results[%d].Err = %v, want %v", i, results[i].Err, want)
+				}
+				if want != nil && results[i].Session != nil {
+					t.Errorf("results[%d] failed but carries a session", i)
+				}
+			}
+
+			sessions, err := svc.ListSessions(ctx, testUser, SessionFilter{})
+			if err != nil {
+				t.Fatalf("ListSessions: %v", err)
+			}
+			if len(sessions) != tt.wantSessions {
+				t.Fatalf("got %d sessions after the batch, want %d", len(sessions), tt.wantSessions)
+			}
+		})
+	}
+}
+
+func TestBatchSessionsRejectsEmptyBatch(t *testing.T) {
+	svc := newTestService(t)
+	if _, _, err := svc.BatchSessions(context.Background(), testUser, BatchRequest{}); !errors.Is(err, ErrBatchEmpty) {
+		t.Fatalf("err = %v, want ErrBatchEmpty", err)
+	}
+}
@@ -1,6 +1,9 @@
 package study
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// Sessions
@@ -8,9 +11,105 @@ var (
 	ErrMissingSubject = errors.New("subject is required")
 	ErrInvalidTiming  = errors.New("start and end time must be provided and end must be after start")
 	ErrUnknownSubject = errors.New("subject does not exist")
+	ErrInvalidRating  = errors.New("ratings must be whole numbers from 1 to 5")
+
+	// Modes and segments
+	ErrInvalidMode      = errors.New("mode must be manual or pomodoro")
+	ErrSegmentsRequired = errors.New("pomodoro sessions need at least one segment")
+	ErrInvalidSegment   = errors.New("segments need a known kind, must end after they start and must not overlap")
+
+	// Pomodoro
+	ErrInvalidPomodoroSettings = errors.New("work must be 1-180 minutes, breaks 1-60 minutes and longBreakEvery 1-12")
+
+	// Overlaps
+	ErrInvalidOverlapPolicy = errors.New("overlapPolicy must be reject, warn or allow")
+	ErrSessionOverlap       = errors.New("session overlaps existing sessions")
+	ErrInvalidResolution    = errors.New("resolve takes two distinct sessionIds and an action of merge or split")
+	ErrSessionsDoNotOverlap = errors.New("sessions do not overlap")
+	ErrCannotSplit          = errors.New("sessions cover the same time; merge them instead")
+
+	// Split and merge
+	ErrInvalidSplitPoint    = errors.New("at must fall inside the session's recorded time")
+	ErrMergeTooFew          = errors.New("merge takes at least two distinct sessionIds")
+	ErrMergeSubjectMismatch = errors.New("only sessions of the same subject can be merged")
+	ErrSessionsNotAdjacent  = errors.New("sessions must be adjacent, with no other session between them")
 
 	// Subjects
-	ErrSubjectNotFound   = errors.New("subject not found")
-	ErrSubjectNameExists = errors.New("subject name already exists")
-	ErrSubjectNameEmpty  = errors.New("subject name is required")
+	ErrSubjectNotFound    = errors.New("subject not found")
+	ErrSubjectNameExists  = errors.New("subject name already exists")
+	ErrSubjectInTrash     = errors.New("a subject with this name is in the trash; restore it instead")
+	ErrSubjectNameEmpty   = errors.New("subject name is required")
+	ErrUnknownParent      = errors.New("parent subject does not exist")
+	ErrSubjectCycle       = errors.New("a subject cannot be moved below itself")
+	ErrSubjectHasChildren = errors.New("subject has child subjects; move or delete them first")
+	ErrSubjectArchived    = errors.New("subject is archived; unarchive it to log new sessions")
+	ErrSubjectMergeSelf   = errors.New("cannot merge a subject into itself")
+
+	// Tags
+	ErrTagNotFound   = errors.New("tag not found")
+	ErrTagNameExists = errors.New("tag name already exists; merge the tags instead")
+	ErrInvalidTag    = errors.New("tags must be 1 to 40 characters")
+	ErrTooManyTags   = errors.New("a session can have at most 20 tags")
+	ErrTagMergeSelf  = errors.New("cannot merge a tag into itself")
+
+	// History
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrRevisionDeleted  = errors.New("cannot revert to a deleted state")
+
+	// Batches
+	ErrBatchEmpty            = errors.New("batch has no operations")
+	ErrInvalidBatchOperation = errors.New("batch operation is malformed")
+	ErrBatchAborted          = errors.New("not applied because another operation in the atomic batch failed")
+
+	// Sync
+	ErrInvalidSyncToken = errors.New("sync token is invalid")
+	ErrSyncTokenExpired = errors.New("sync token is too old; run a full sync")
+
+	// Concurrency
+	ErrVersionConflict = errors.New("resource was modified by another request")
 )
+
+// RatingError names the self-assessment that is outside the allowed range.
+// It matches ErrInvalidRating.
+type RatingError struct {
+	Field string
+}
+
+func (e *RatingError) Error() string {
+	return fmt.Sprintf("%s must be a whole number from %d to %d", e.Field, minRating, maxRating)
+}
+
+func (e *RatingError) Unwrap() error {
+	return ErrInvalidRating
+}
+
+// TimingError names the session time that is missing or out of order. It
+// matches ErrInvalidTiming.
+type TimingError struct {
+	Field  string
+	Reason string
+}
+
+func (e *TimingError) Error() string {
+	return e.Field + " " + e.Reason
+}
+
+func (e *TimingError) Unwrap() error {
+	return ErrInvalidTiming
+}
+
+// SegmentError names the invalid field of the segment at Index, counted in
+// the order the segments were sent. It matches ErrInvalidSegment.
+type SegmentError struct {
+	Index  int
+	Field  string
+	Reason string
+}
+
+func (e *SegmentError) Error() string {
+	return fmt.Sprintf("segments[%d].%s %s", e.Index, e.Field, e.Reason)
+}
+
+func (e *SegmentError) Unwrap() error {
+	return ErrInvalidSegment
+}
@@ -1,36 +1,76 @@
 package study
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 
 	"studytracker/internal/auth"
+	"studytracker/internal/platform/apierror"
+	"studytracker/internal/platform/etag"
+	"studytracker/internal/platform/mergepatch"
 )
 
 // Handler exposes HTTP endpoints for study resources.
 type Handler struct {
-	service *Service
+	service      *Service
+	batchMaxSize int
+	logger       *slog.Logger
 }
 
-// NewHandler creates a study handler bound to a service.
-func NewHandler(service *Service) *Handler {
-	return &Handler{service: service}
+// NewHandler creates a study handler bound to a service. batchMaxSize caps
+// the operations accepted by one batch request.
+func NewHandler(service *Service, batchMaxSize int, logger *slog.Logger) *Handler {
+	return &Handler{service: service, batchMaxSize: batchMaxSize, logger: logger}
 }
 
 // RegisterRoutes mounts study routes onto the provided router.
-// All study endpoints require authentication.
-func (h *Handler) RegisterRoutes(router fiber.Router, requireAuth fiber.Handler) {
+// All study endpoints require authentication; idempotent guards the create
+// endpoints so retried requests with an Idempotency-Key are replayed.
+func (h *Handler) RegisterRoutes(router fiber.Router, requireAuth, idempotent fiber.Handler) {
 	router.Get("/subjects", requireAuth, h.listSubjects)
-	router.Post("/subjects", requireAuth, h.createSubject)
+	router.Get("/subjects/tree", requireAuth, h.subjectTree)
+	router.Get("/subjects/duplicates", requireAuth, h.subjectDuplicates)
+	router.Post("/subjects", requireAuth, idempotent, h.createSubject)
 	router.Put("/subjects/:id", requireAuth, h.updateSubject)
+	router.Patch("/subjects/:id", requireAuth, h.patchSubject)
 	router.Delete("/subjects/:id", requireAuth, h.deleteSubject)
+	router.Post("/subjects/:id/move", requireAuth, h.moveSubject)
+	router.Post("/subjects/:id/archive", requireAuth, h.archiveSubject)
+	router.Post("/subjects/:id/unarchive", requireAuth, h.unarchiveSubject)
+	router.Post("/subjects/:id/merge-into/:targetId", requireAuth, h.mergeSubject)
 
 	router.Get("/study-sessions", requireAuth, h.listSessions)
-	router.Post("/study-sessions", requireAuth, h.createSession)
+	router.Post("/study-sessions", requireAuth, idempotent, h.createSession)
+	router.Post("/study-sessions/batch", requireAuth, idempotent, h.batchSessions)
+	router.Get("/study-sessions/overlaps", requireAuth, h.listOverlaps)
+	router.Post("/study-sessions/overlaps/resolve", requireAuth, h.resolveOverlap)
+	router.Post("/study-sessions/merge", requireAuth, h.mergeSessions)
 	router.Put("/study-sessions/:id", requireAuth, h.updateSession)
+	router.Patch("/study-sessions/:id", requireAuth, h.patchSession)
 	router.Delete("/study-sessions/:id", requireAuth, h.deleteSession)
+	router.Get("/study-sessions/:id/history", requireAuth, h.sessionHistory)
+	router.Post("/study-sessions/:id/revert", requireAuth, h.revertSession)
+	router.Post("/study-sessions/:id/split", requireAuth, h.splitSession)
+	router.Get("/tags", requireAuth, h.listTags)
+	router.Put("/tags/:id", requireAuth, h.renameTag)
+	router.Post("/tags/:id/merge-into/:targetId", requireAuth, h.mergeTags)
 	router.Get("/progress/summary", requireAuth, h.handleSummary)
+	router.Get("/insights/ratings", requireAuth, h.ratingInsights)
+	router.Get("/pomodoro/settings", requireAuth, h.pomodoroSettings)
+	router.Put("/pomodoro/settings", requireAuth, h.savePomodoroSettings)
+	router.Get("/pomodoro/stats", requireAuth, h.pomodoroStats)
+	router.Get("/preferences", requireAuth, h.preferences)
+	router.Put("/preferences", requireAuth, h.savePreferences)
+	router.Get("/sync", requireAuth, h.sync)
+	router.Get("/trash", requireAuth, h.listTrash)
+	router.Post("/study-sessions/:id/restore", requireAuth, h.restoreSession)
+	router.Post("/subjects/:id/restore", requireAuth, h.restoreSubject)
 }
 
 func (h *Handler) listSessions(c *fiber.Ctx) error {
@@ -38,11 +78,16 @@ func (h *Handler) listSessions(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	items, err := h.service.ListSessions(userID)
+	var filter SessionFilter
+	for _, tag := range c.Context().QueryArgs().PeekMulti("tag") {
+		filter.Tags = append(filter.Tags, string(tag))
+	}
+
+	items, err := h.service.ListSessions(c.UserContext(), userID, filter)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return mapError(err)
 	}
-	return c.JSON(items)
+	return etag.JSON(c, items)
 }
 
 func (h *Handler) createSession(c *fiber.Ctx) error {
@@ -52,19 +97,15 @@ func (h *Handler) createSession(c *fiber.Ctx) error {
 	}
 	var session StudySession
 	if err := c.BodyParser(&session); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+		return apierror.InvalidPayload()
 	}
 
-	created, err := h.service.CreateSession(userID, session)
+	created, err := h.service.CreateSession(c.UserContext(), userID, session)
 	if err != nil {
-		switch {
-		case errors.Is(err, ErrMissingSubject), errors.Is(err, ErrInvalidTiming), errors.Is(err, ErrUnknownSubject):
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
-		default:
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
-		}
+		return mapError(err)
 	}
 
+	etag.SetVersion(c, created.Version)
 	return c.Status(fiber.StatusCreated).JSON(created)
 }
 
@@ -80,23 +121,102 @@ func (h *Handler) updateSession(c *fiber.Ctx) error {
 
 	var session StudySession
 	if err := c.BodyParser(&session); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+		return apierror.InvalidPayload()
 	}
 	session.ID = id
+	// The body's version is ignored; only If-Match makes the write conditional.
+	session.Version = etag.IfMatch(c)
 
-	updated, err := h.service.UpdateSession(userID, session)
+	updated, err := h.service.UpdateSession(c.UserContext(), userID, session)
 	if err != nil {
+		return mapError(err)
+	}
+
+	etag.SetVersion(c, updated.Version)
+	return c.JSON(updated)
+}
+
+func (h *Handler) patchSession(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return fiber.ErrNotFound
+	}
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+	if err := requireMergePatch(c); err != nil {
+		return err
+	}
+
+	updated, err := h.service.PatchSession(c.UserContext(), userID, id, c.Body(), etag.IfMatch(c))
+	if err != nil {
+		return mapError(err)
+	}
+
+	etag.SetVersion(c, updated.Version)
+	return c.JSON(updated)
+}
+
+// BatchResponse reports the outcome of a batch request.
+type BatchResponse struct {
+	Committed bool                `json:"committed"`
+	Results   []BatchItemResponse `json:"results"`
+}
+
+// BatchItemResponse is one operation's outcome. Status is the HTTP status the
+// equivalent single request would have returned.
+type BatchItemResponse struct {
+	Index   int            `json:"index"`
+	Op      string         `json:"op"`
+	ID      string         `json:"id,omitempty"`
+	Status  int            `json:"status"`
+	Session *StudySession  `json:"session,omitempty"`
+	Error   *apierror.Body `json:"error,omitempty"`
+}
+
+func (h *Handler) batchSessions(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+	var req BatchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apierror.InvalidPayload()
+	}
+	if len(req.Operations) > h.batchMaxSize {
+		return apierror.New(fiber.StatusRequestEntityTooLarge, "batch_too_large",
+			fmt.Sprintf("a batch may contain at most %d operations", h.batchMaxSize))
+	}
+
+	results, committed, err := h.service.BatchSessions(c.UserContext(), userID, req)
+	if err != nil {
+		return mapError(err)
+	}
+
+	response := BatchResponse{Committed: committed, Results: make([]BatchItemResponse, len(results))}
+	for i, result := range results {
+		item := BatchItemResponse{Index: result.Index, Op: result.Op, ID: result.ID, Session: result.Session}
 		switch {
-		case errors.Is(err, ErrNotFound):
-			return fiber.NewError(fiber.StatusNotFound, err.Error())
-		case errors.Is(err, ErrMissingSubject), errors.Is(err, ErrInvalidTiming), errors.Is(err, ErrUnknownSubject):
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		case result.Err != nil:
+			apiErr := apierror.Normalize(mapError(result.Err))
+			if apiErr.Status >= fiber.StatusInternalServerError {
+				h.logger.ErrorContext(c.UserContext(), "batch operation failed", "index", result.Index, "op", result.Op, "error", result.Err)
+			}
+			item.Status = apiErr.Status
+			item.Session = nil
+			item.Error = &apierror.Body{Code: apiErr.Code, Message: apiErr.Message, Details: apiErr.Details}
+		case result.Op == BatchCreate:
+			item.Status = fiber.StatusCreated
+		case result.Op == BatchDelete:
+			item.Status = fiber.StatusNoContent
 		default:
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+			item.Status = fiber.StatusOK
 		}
+		response.Results[i] = item
 	}
 
-	return c.JSON(updated)
+	return c.JSON(response)
 }
 
 func (h *Handler) deleteSession(c *fiber.Ctx) error {
@@ -109,11 +229,8 @@ func (h *Handler) deleteSession(c *fiber.Ctx) error {
 		return err
 	}
 
-	if err := h.service.DeleteSession(userID, id); err != nil {
-		if errors.Is(err, ErrNotFound) {
-			return fiber.NewError(fiber.StatusNotFound, err.Error())
-		}
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	if err := h.service.DeleteSession(c.UserContext(), userID, id, etag.IfMatch(c)); err != nil {
+		return mapError(err)
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
@@ -124,11 +241,289 @@ func (h *Handler) handleSummary(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	summary, err := h.service.BuildSummary(userID)
+	depth := c.QueryInt("subjectDepth", 0)
+	if depth < 0 {
+		return apierror.Validation(apierror.CodeValidationFailed, "subjectDepth must not be negative",
+			apierror.Field("subjectDepth", "must be 0 or more"))
+	}
+	summary, err := h.service.BuildSummary(c.UserContext(), userID, SummaryOptions{SubjectDepth: depth})
+	if err != nil {
+		return mapError(err)
+	}
+	return etag.JSON(c, summary)
+}
+
+func (h *Handler) sync(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+	changes, err := h.service.Sync(c.UserContext(), userID, c.Query("since"))
+	if err != nil {
+		return mapError(err)
+	}
+	return c.JSON(changes)
+}
+
+func (h *Handler) ratingInsights(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+	insights, err := h.service.RatingInsights(c.UserContext(), userID)
+	if err != nil {
+		return mapError(err)
+	}
+	return etag.JSON(c, insights)
+}
+
+func (h *Handler) pomodoroSettings(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+	settings, err := h.service.PomodoroSettings(c.UserContext(), userID)
+	if err != nil {
+		return mapError(err)
+	}
+	return c.JSON(settings)
+}
+
+func (h *Handler) savePomodoroSettings(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	var settings PomodoroSettings
+	if err := c.BodyParser(&settings); err != nil {
+		return apierror.InvalidPayload()
+	}
+
+	saved, err := h.service.SavePomodoroSettings(c.UserContext(), userID, settings)
+	if err != nil {
+		return mapError(err)
+	}
+	return c.JSON(saved)
+}
+
+func (h *Handler) pomodoroStats(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+	stats, err := h.service.PomodoroStats(c.UserContext(), userID)
+	if err != nil {
+		return mapError(err)
+	}
+	return etag.JSON(c, stats)
+}
+
+func (h *Handler) preferences(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+	prefs, err := h.service.Preferences(c.UserContext(), userID)
+	if err != nil {
+		return mapError(err)
+	}
+	return c.JSON(prefs)
+}
+
+func (h *Handler) savePreferences(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	var prefs Preferences
+	if err := c.BodyParser(&prefs); err != nil {
+		return apierror.InvalidPayload()
+	}
+
+	saved, err := h.service.SavePreferences(c.UserContext(), userID, prefs)
+	if err != nil {
+		return mapError(err)
+	}
+	return c.JSON(saved)
+}
+
+func (h *Handler) listOverlaps(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+	pairs, err := h.service.ListOverlaps(c.UserContext(), userID)
+	if err != nil {
+		return mapError(err)
+	}
+	return etag.JSON(c, pairs)
+}
+
+func (h *Handler) resolveOverlap(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	var req ResolveOverlapRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apierror.InvalidPayload()
+	}
+
+	sessions, err := h.service.ResolveOverlap(c.UserContext(), userID, req)
+	if err != nil {
+		return mapError(err)
+	}
+	return c.JSON(sessions)
+}
+
+func (h *Handler) listTrash(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+	trash, err := h.service.ListTrash(c.UserContext(), userID)
+	if err != nil {
+		return mapError(err)
+	}
+	return c.JSON(trash)
+}
+
+func (h *Handler) restoreSession(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return fiber.ErrNotFound
+	}
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	restored, err := h.service.RestoreSession(c.UserContext(), userID, id, etag.IfMatch(c))
+	if err != nil {
+		return mapError(err)
+	}
+
+	etag.SetVersion(c, restored.Version)
+	return c.JSON(restored)
+}
+
+// RevertRequest names the revision whose resulting state a session should
+// return to.
+type RevertRequest struct {
+	RevisionID string `json:"revisionId"`
+}
+
+func (h *Handler) sessionHistory(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return fiber.ErrNotFound
+	}
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := h.service.SessionHistory(c.UserContext(), userID, id)
+	if err != nil {
+		return mapError(err)
+	}
+	return etag.JSON(c, revisions)
+}
+
+func (h *Handler) revertSession(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return fiber.ErrNotFound
+	}
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	var req RevertRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apierror.InvalidPayload()
+	}
+	if strings.TrimSpace(req.RevisionID) == "" {
+		return apierror.Validation("revision_required", "revisionId is required",
+			apierror.Field("revisionId", "is required"))
+	}
+
+	reverted, err := h.service.RevertSession(c.UserContext(), userID, id, utils.CopyString(req.RevisionID), etag.IfMatch(c))
+	if err != nil {
+		return mapError(err)
+	}
+
+	etag.SetVersion(c, reverted.Version)
+	return c.JSON(reverted)
+}
+
+func (h *Handler) splitSession(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return fiber.ErrNotFound
+	}
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	var req SplitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apierror.InvalidPayload()
+	}
+	if req.At.IsZero() {
+		return apierror.Validation("split_point_required", "at is required",
+			apierror.Field("at", "is required"))
+	}
+
+	parts, err := h.service.SplitSession(c.UserContext(), userID, id, req, etag.IfMatch(c))
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return mapError(err)
 	}
-	return c.JSON(summary)
+	return c.Status(fiber.StatusCreated).JSON(parts)
+}
+
+func (h *Handler) mergeSessions(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	var req MergeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apierror.InvalidPayload()
+	}
+
+	merged, err := h.service.MergeSessions(c.UserContext(), userID, req)
+	if err != nil {
+		return mapError(err)
+	}
+
+	etag.SetVersion(c, merged.Version)
+	return c.JSON(merged)
+}
+
+func (h *Handler) restoreSubject(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return fiber.ErrNotFound
+	}
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	restored, err := h.service.RestoreSubject(c.UserContext(), userID, id, etag.IfMatch(c))
+	if err != nil {
+		return mapError(err)
+	}
+
+	etag.SetVersion(c, restored.Version)
+	return c.JSON(restored)
 }
 
 // Subject handlers ------------------------------------------------------------
@@ -138,11 +533,23 @@ func (h *Handler) listSubjects(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	subjects, err := h.service.ListSubjects(userID)
+	subjects, err := h.service.ListSubjects(c.UserContext(), userID, subjectFilter(c))
+	if err != nil {
+		return mapError(err)
+	}
+	return etag.JSON(c, subjects)
+}
+
+func (h *Handler) subjectTree(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+	tree, err := h.service.SubjectTree(c.UserContext(), userID, subjectFilter(c))
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return mapError(err)
 	}
-	return c.JSON(subjects)
+	return etag.JSON(c, tree)
 }
 
 func (h *Handler) createSubject(c *fiber.Ctx) error {
@@ -152,19 +559,15 @@ func (h *Handler) createSubject(c *fiber.Ctx) error {
 	}
 	var subject Subject
 	if err := c.BodyParser(&subject); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+		return apierror.InvalidPayload()
 	}
 
-	created, err := h.service.CreateSubject(userID, subject)
+	created, err := h.service.CreateSubject(c.UserContext(), userID, subject)
 	if err != nil {
-		switch {
-		case errors.Is(err, ErrSubjectNameEmpty), errors.Is(err, ErrSubjectNameExists):
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
-		default:
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
-		}
+		return mapError(err)
 	}
 
+	etag.SetVersion(c, created.Version)
 	return c.Status(fiber.StatusCreated).JSON(created)
 }
 
@@ -180,22 +583,39 @@ func (h *Handler) updateSubject(c *fiber.Ctx) error {
 
 	var subject Subject
 	if err := c.BodyParser(&subject); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+		return apierror.InvalidPayload()
 	}
 	subject.ID = id
+	subject.Version = etag.IfMatch(c)
 
-	updated, err := h.service.UpdateSubject(userID, subject)
+	updated, err := h.service.UpdateSubject(c.UserContext(), userID, subject)
 	if err != nil {
-		switch {
-		case errors.Is(err, ErrSubjectNotFound):
-			return fiber.NewError(fiber.StatusNotFound, err.Error())
-		case errors.Is(err, ErrSubjectNameEmpty), errors.Is(err, ErrSubjectNameExists):
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
-		default:
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
-		}
+		return mapError(err)
 	}
 
+	etag.SetVersion(c, updated.Version)
+	return c.JSON(updated)
+}
+
+func (h *Handler) patchSubject(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return fiber.ErrNotFound
+	}
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+	if err := requireMergePatch(c); err != nil {
+		return err
+	}
+
+	updated, err := h.service.PatchSubject(c.UserContext(), userID, id, c.Body(), etag.IfMatch(c))
+	if err != nil {
+		return mapError(err)
+	}
+
+	etag.SetVersion(c, updated.Version)
 	return c.JSON(updated)
 }
 
@@ -209,16 +629,262 @@ func (h *Handler) deleteSubject(c *fiber.Ctx) error {
 		return err
 	}
 
-	if err := h.service.DeleteSubject(userID, id); err != nil {
-		if errors.Is(err, ErrSubjectNotFound) {
-			return fiber.NewError(fiber.StatusNotFound, err.Error())
-		}
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	if err := h.service.DeleteSubject(c.UserContext(), userID, id, etag.IfMatch(c)); err != nil {
+		return mapError(err)
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+func (h *Handler) moveSubject(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return fiber.ErrNotFound
+	}
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	var req MoveSubjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apierror.InvalidPayload()
+	}
+
+	moved, err := h.service.MoveSubject(c.UserContext(), userID, id, utils.CopyString(req.ParentID), etag.IfMatch(c))
+	if err != nil {
+		return mapError(err)
+	}
+
+	etag.SetVersion(c, moved.Version)
+	return c.JSON(moved)
+}
+
+func (h *Handler) archiveSubject(c *fiber.Ctx) error {
+	return h.setSubjectArchived(c, h.service.ArchiveSubject)
+}
+
+func (h *Handler) unarchiveSubject(c *fiber.Ctx) error {
+	return h.setSubjectArchived(c, h.service.UnarchiveSubject)
+}
+
+func (h *Handler) setSubjectArchived(c *fiber.Ctx, apply func(ctx context.Context, userID, id string, version int) (Subject, error)) error {
+	id := c.Params("id")
+	if id == "" {
+		return fiber.ErrNotFound
+	}
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	subject, err := apply(c.UserContext(), userID, id, etag.IfMatch(c))
+	if err != nil {
+		return mapError(err)
+	}
+
+	etag.SetVersion(c, subject.Version)
+	return c.JSON(subject)
+}
+
+func (h *Handler) subjectDuplicates(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+	suggestions, err := h.service.SubjectDuplicates(c.UserContext(), userID)
+	if err != nil {
+		return mapError(err)
+	}
+	return etag.JSON(c, suggestions)
+}
+
+func (h *Handler) mergeSubject(c *fiber.Ctx) error {
+	sourceID, targetID := c.Params("id"), c.Params("targetId")
+	if sourceID == "" || targetID == "" {
+		return fiber.ErrNotFound
+	}
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	merged, err := h.service.MergeSubject(c.UserContext(), userID, sourceID, targetID)
+	if err != nil {
+		return mapError(err)
+	}
+
+	etag.SetVersion(c, merged.Version)
+	return c.JSON(merged)
+}
+
+// subjectFilter reads ?includeArchived=true.
+func subjectFilter(c *fiber.Ctx) SubjectFilter {
+	return SubjectFilter{IncludeArchived: c.QueryBool("includeArchived", false)}
+}
+
+// Tag handlers ----------------------------------------------------------------
+
+func (h *Handler) listTags(c *fiber.Ctx) error {
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+	tags, err := h.service.ListTags(c.UserContext(), userID, c.Query("q"), c.QueryInt("limit"))
+	if err != nil {
+		return mapError(err)
+	}
+	return etag.JSON(c, tags)
+}
+
+func (h *Handler) renameTag(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return fiber.ErrNotFound
+	}
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	var tag Tag
+	if err := c.BodyParser(&tag); err != nil {
+		return apierror.InvalidPayload()
+	}
+
+	renamed, err := h.service.RenameTag(c.UserContext(), userID, id, tag.Name)
+	if err != nil {
+		return mapError(err)
+	}
+	return c.JSON(renamed)
+}
+
+func (h *Handler) mergeTags(c *fiber.Ctx) error {
+	sourceID, targetID := c.Params("id"), c.Params("targetId")
+	if sourceID == "" || targetID == "" {
+		return fiber.ErrNotFound
+	}
+	userID, err := userIDFromCtx(c)
+	if err != nil {
+		return err
+	}
+
+	merged, err := h.service.MergeTags(c.UserContext(), userID, sourceID, targetID)
+	if err != nil {
+		return mapError(err)
+	}
+	return c.JSON(merged)
+}
+
+// errorMappings translates domain errors into API error codes. Errors not
+// listed here are reported as opaque internal errors.
+var errorMappings = []apierror.Mapping{
+	{Target: ErrNotFound, Status: fiber.StatusNotFound, Code: "session_not_found"},
+	{Target: ErrMissingSubject, Status: fiber.StatusBadRequest, Code: "subject_required",
+		Details: []apierror.FieldError{apierror.Field("subject", "is required")}},
+	{Target: ErrInvalidTiming, Status: fiber.StatusBadRequest, Code: "invalid_timing"},
+	{Target: ErrInvalidRating, Status: fiber.StatusBadRequest, Code: "invalid_rating"},
+	{Target: ErrInvalidMode, Status: fiber.StatusBadRequest, Code: "invalid_mode",
+		Details: []apierror.FieldError{apierror.Field("mode", "must be manual or pomodoro")}},
+	{Target: ErrSegmentsRequired, Status: fiber.StatusBadRequest, Code: "segments_required",
+		Details: []apierror.FieldError{apierror.Field("segments", "is required for pomodoro sessions")}},
+	{Target: ErrInvalidSegment, Status: fiber.StatusBadRequest, Code: "invalid_segment"},
+	{Target: ErrInvalidPomodoroSettings, Status: fiber.StatusBadRequest, Code: "invalid_pomodoro_settings"},
+	{Target: ErrInvalidOverlapPolicy, Status: fiber.StatusBadRequest, Code: "invalid_overlap_policy",
+		Details: []apierror.FieldError{apierror.Field("overlapPolicy", "must be reject, warn or allow")}},
+	{Target: ErrInvalidResolution, Status: fiber.StatusBadRequest, Code: "invalid_resolution"},
+	{Target: ErrSessionsDoNotOverlap, Status: fiber.StatusConflict, Code: "sessions_do_not_overlap"},
+	{Target: ErrCannotSplit, Status: fiber.StatusConflict, Code: "cannot_split"},
+	{Target: ErrInvalidSplitPoint, Status: fiber.StatusBadRequest, Code: "invalid_split_point",
+		Details: []apierror.FieldError{apierror.Field("at", "must fall inside the session's recorded time")}},
+	{Target: ErrMergeTooFew, Status: fiber.StatusBadRequest, Code: "merge_too_few",
+		Details: []apierror.FieldError{apierror.Field("sessionIds", "must list at least two distinct sessions")}},
+	{Target: ErrMergeSubjectMismatch, Status: fiber.StatusConflict, Code: "merge_subject_mismatch"},
+	{Target: ErrSessionsNotAdjacent, Status: fiber.StatusConflict, Code: "sessions_not_adjacent"},
+	{Target: ErrUnknownSubject, Status: fiber.StatusBadRequest, Code: "unknown_subject",
+		Details: []apierror.FieldError{apierror.Field("subject", "does not exist")}},
+	{Target: ErrSubjectNotFound, Status: fiber.StatusNotFound, Code: "subject_not_found"},
+	{Target: ErrSubjectNameExists, Status: fiber.StatusConflict, Code: "subject_name_exists",
+		Details: []apierror.FieldError{apierror.Field("name", "is already in use")}},
+	{Target: ErrSubjectInTrash, Status: fiber.StatusConflict, Code: "subject_in_trash",
+		Details: []apierror.FieldError{apierror.Field("name", "belongs to a deleted subject")}},
+	{Target: ErrSubjectNameEmpty, Status: fiber.StatusBadRequest, Code: "subject_name_required",
+		Details: []apierror.FieldError{apierror.Field("name", "is required")}},
+	{Target: ErrUnknownParent, Status: fiber.StatusBadRequest, Code: "unknown_parent",
+		Details: []apierror.FieldError{apierror.Field("parentId", "does not exist")}},
+	{Target: ErrSubjectCycle, Status: fiber.StatusConflict, Code: "subject_cycle"},
+	{Target: ErrSubjectHasChildren, Status: fiber.StatusConflict, Code: "subject_has_children"},
+	{Target: ErrSubjectMergeSelf, Status: fiber.StatusBadRequest, Code: "subject_merge_self"},
+	{Target: ErrSubjectArchived, Status: fiber.StatusConflict, Code: "subject_archived",
+		Details: []apierror.FieldError{apierror.Field("subject", "is archived")}},
+	{Target: ErrTagNotFound, Status: fiber.StatusNotFound, Code: "tag_not_found"},
+	{Target: ErrTagNameExists, Status: fiber.StatusConflict, Code: "tag_name_exists",
+		Details: []apierror.FieldError{apierror.Field("name", "is already in use")}},
+	{Target: ErrInvalidTag, Status: fiber.StatusBadRequest, Code: "invalid_tag",
+		Details: []apierror.FieldError{apierror.Field("tags", "must be 1 to 40 characters")}},
+	{Target: ErrTooManyTags, Status: fiber.StatusBadRequest, Code: "too_many_tags",
+		Details: []apierror.FieldError{apierror.Field("tags", "must have at most 20 entries")}},
+	{Target: ErrTagMergeSelf, Status: fiber.StatusBadRequest, Code: "tag_merge_self"},
+	{Target: ErrRevisionNotFound, Status: fiber.StatusNotFound, Code: "revision_not_found"},
+	{Target: ErrRevisionDeleted, Status: fiber.StatusConflict, Code: "revision_deleted"},
+	{Target: mergepatch.ErrInvalidPatch, Status: fiber.StatusBadRequest, Code: apierror.CodeInvalidPayload},
+	{Target: ErrBatchEmpty, Status: fiber.StatusBadRequest, Code: "batch_empty",
+		Details: []apierror.FieldError{apierror.Field("operations", "must not be empty")}},
+	{Target: ErrInvalidBatchOperation, Status: fiber.StatusBadRequest, Code: "invalid_batch_operation"},
+	{Target: ErrBatchAborted, Status: fiber.StatusFailedDependency, Code: "batch_aborted"},
+	{Target: ErrInvalidSyncToken, Status: fiber.StatusBadRequest, Code: "invalid_sync_token",
+		Details: []apierror.FieldError{apierror.Field("since", "is not a token returned by /sync")}},
+	{Target: ErrSyncTokenExpired, Status: fiber.StatusGone, Code: "sync_token_expired"},
+	{Target: ErrVersionConflict, Status: fiber.StatusPreconditionFailed, Code: "version_conflict"},
+}
+
+// requireMergePatch accepts application/merge-patch+json and, for clients
+// that cannot set it, plain application/json.
+func requireMergePatch(c *fiber.Ctx) error {
+	switch utils.ToLower(strings.TrimSpace(strings.Split(c.Get(fiber.HeaderContentType), ";")[0])) {
+	case mergepatch.ContentType, fiber.MIMEApplicationJSON:
+		return nil
+	}
+	return apierror.New(fiber.StatusUnsupportedMediaType, "unsupported_media_type",
+		"PATCH expects "+mergepatch.ContentType)
+}
+
+func mapError(err error) error {
+	// Overlap rejections name the conflicting sessions and validation errors
+	// the offending field, so they cannot use a static mapping.
+	var overlap *OverlapError
+	if errors.As(err, &overlap) {
+		details := make([]apierror.FieldError, len(overlap.SessionIDs))
+		for i, id := range overlap.SessionIDs {
+			details[i] = apierror.Field("startTime", "overlaps session "+id)
+		}
+		return &apierror.Error{Status: fiber.StatusConflict, Code: "session_overlap",
+			Message: ErrSessionOverlap.Error(), Details: details, Err: err}
+	}
+	var rating *RatingError
+	if errors.As(err, &rating) {
+		return invalidField(err, ErrInvalidRating, "invalid_rating",
+			apierror.Field(rating.Field, fmt.Sprintf("must be a whole number from %d to %d", minRating, maxRating)))
+	}
+	var timing *TimingError
+	if errors.As(err, &timing) {
+		return invalidField(err, ErrInvalidTiming, "invalid_timing", apierror.Field(timing.Field, timing.Reason))
+	}
+	var segment *SegmentError
+	if errors.As(err, &segment) {
+		return invalidField(err, ErrInvalidSegment, "invalid_segment",
+			apierror.Field(fmt.Sprintf("segments[%d].%s", segment.Index, segment.Field), segment.Reason))
+	}
+	return apierror.Map(err, errorMappings...)
+}
+
+// invalidField reports err as a 400 with code, the sentinel's message and the
+// failing field as its only detail.
+func invalidField(err, sentinel error, code string, field apierror.FieldError) error {
+	return &apierror.Error{Status: fiber.StatusBadRequest, Code: code,
+		Message: sentinel.Error(), Details: []apierror.FieldError{field}, Err: err}
+}
+
 func userIDFromCtx(c *fiber.Ctx) (string, error) {
 	userID, ok := c.Locals(auth.ContextUserIDKey).(string)
 	if !ok || userID == "" {
@@ -0,0 +1,68 @@
+package study
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"studytracker/internal/platform/apierror"
+)
+
+func TestMapErrorNamesTheFailingField(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   string
+		status int
+		want   []apierror.FieldError
+	}{
+		{
+			name: "timing",
+			err:  &TimingError{Field: "endTime", Reason: "must be after startTime"},
+			code: "invalid_timing", status: 400,
+			want: []apierror.FieldError{apierror.Field("endTime", "must be after startTime")},
+		},
+		{
+			name: "segment",
+			err:  &SegmentError{Index: 2, Field: "kind", Reason: "must be focus, short_break or long_break"},
+			code: "invalid_segment", status: 400,
+			want: []apierror.FieldError{apierror.Field("segments[2].kind", "must be focus, short_break or long_break")},
+		},
+		{
+			name: "rating",
+			err:  fmt.Errorf("batch item: %w", &RatingError{Field: "mood"}),
+			code: "invalid_rating", status: 400,
+			want: []apierror.FieldError{apierror.Field("mood", "must be a whole number from 1 to 5")},
+		},
+		{
+			name: "overlap",
+			err:  &OverlapError{SessionIDs: []string{"a", "b"}},
+			code: "session_overlap", status: 409,
+			want: []apierror.FieldError{
+				apierror.Field("startTime", "overlaps session a"),
+				apierror.Field("startTime", "overlaps session b"),
+			},
+		},
+		{
+			name: "static mapping",
+			err:  ErrSubjectNameExists,
+			code: "subject_name_exists", status: 409,
+			want: []apierror.FieldError{apierror.Field("name", "is already in use")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiErr *apierror.Error
+			if !errors.As(mapError(tt.err), &apiErr) {
+				t.Fatalf("mapError(%v) is not an API error", tt.err)
+			}
+			if apiErr.Code != tt.code || apiErr.Status != tt.status {
+				t.Fatalf("got %d %s, want %d %s", apiErr.Status, apiErr.Code, tt.status, tt.code)
+			}
+			if !reflect.DeepEqual(apiErr.Details, tt.want) {
+				t.Fatalf("details = %v, want %v", apiErr.Details, tt.want)
+			}
+		})
+	}
+}
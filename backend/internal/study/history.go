@@ -0,0 +1,105 @@
+package study
+
+import (
+	"context"
+	"time"
+)
+
+// SessionHistory returns a session's revisions, oldest first. Sessions
+// logged before history was recorded return an empty list.
+func (s *Service) SessionHistory(ctx context.Context, userID, id string) ([]SessionRevision, error) {
+	if s.revisions == nil {
+		return []SessionRevision{}, nil
+	}
+	revisions, err := s.revisions.ListForSession(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		if _, err := s.sessions.Get(userID, id); err != nil {
+			return nil, err
+		}
+		return []SessionRevision{}, nil
+	}
+	return revisions, nil
+}
+
+// RevertSession sets a session back to the state recorded after the given
+// revision. The revert is validated like any update and is itself recorded.
+func (s *Service) RevertSession(ctx context.Context, userID, id, revisionID string, version int) (StudySession, error) {
+	if s.revisions == nil {
+		return StudySession{}, ErrRevisionNotFound
+	}
+	revision, err := s.revisions.Get(userID, revisionID)
+	if err != nil {
+		return StudySession{}, err
+	}
+	if revision.SessionID != id {
+		return StudySession{}, ErrRevisionNotFound
+	}
+	if revision.After == nil {
+		return StudySession{}, ErrRevisionDeleted
+	}
+
+	target := *revision.After
+	target.ID = id
+	target.SubjectID = ""
+	target.SubjectColor = ""
+	target.Version = version
+
+	reverted, err := s.updateSession(ctx, userID, target, RevisionRevert)
+	if err != nil {
+		return StudySession{}, err
+	}
+	s.logger.InfoContext(ctx, "study session reverted", "userId", userID, "sessionId", id, "revisionId", revisionID)
+	return reverted, nil
+}
+
+// atomically runs fn on a service bound to one transaction, reusing the
+// current transaction when s is already scoped to one.
+func (s *Service) atomically(ctx context.Context, fn func(scoped *Service) error) error {
+	if s.inTx || s.tx == nil {
+		return fn(s)
+	}
+	return s.tx.InTx(ctx, func(tx TxRepositories) error {
+		return fn(s.scoped(tx))
+	})
+}
+
+// recordRevision appends a history entry for a session write. The actor is
+// the authenticated user making the change.
+func (s *Service) recordRevision(actorID, action string, before, after *StudySession) error {
+	return s.appendRevisions(newRevision(actorID, action, before, after))
+}
+
+// appendRevisions stores revisions built with newRevision in one write.
+func (s *Service) appendRevisions(revisions ...SessionRevision) error {
+	if s.revisions == nil || len(revisions) == 0 {
+		return nil
+	}
+	return s.revisions.Append(revisions...)
+}
+
+// newRevision describes a session write by actorID. before is nil for a
+// create and after is nil for a delete.
+func newRevision(actorID, action string, before, after *StudySession) SessionRevision {
+	revision := SessionRevision{
+		ID:        generateID(),
+		ActorID:   actorID,
+		Action:    action,
+		Before:    before,
+		After:     after,
+		CreatedAt: time.Now().UTC(),
+	}
+	switch {
+	case after != nil:
+		revision.SessionID = after.ID
+		revision.UserID = after.UserID
+		revision.Revision = after.Version
+	case before != nil:
+		revision.SessionID = before.ID
+		revision.UserID = before.UserID
+		revision.Revision = before.Version + 1
+	}
+	return revision
+}
@@ -0,0 +1,138 @@
+package study
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSessionHistoryRecordsEveryWrite(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	session := mustCreateSession(t, svc, "Physics", at(8, 0), at(9, 0))
+	if _, err := svc.PatchSession(ctx, testUser, session.ID, []byte(`{"notes":"reviewed"}`), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteSession(ctx, testUser, session.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.RestoreSession(ctx, testUser, session.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := svc.SessionHistory(ctx, testUser, session.ID)
+	if err != nil {
+		t.Fatalf("SessionHistory: %v", err)
+	}
+	want := []string{RevisionCreate, RevisionUpdate, RevisionDelete, RevisionRestore}
+	if len(history) != len(want) {
+		t.Fatalf("got %d revisions, want %d", len(history), len(want))
+	}
+	for i, revision := range history {
+		if revision.Action != want[i] || revision.ActorID != testUser || revision.Revision != i+1 {
+			t.Errorf("revision %d = %s by %s at version %d, want %s by %s at version %d",
+				i, revision.Action, revision.ActorID, revision.Revision, want[i], testUser, i+1)
+		}
+	}
+	if history[0].Before != nil || history[2].After != nil {
+		t.Error("create must have no before state and delete no after state")
+	}
+	if history[1].Before.Notes != "" || history[1].After.Notes != "reviewed" {
+		t.Errorf("update recorded notes %q -> %q", history[1].Before.Notes, history[1].After.Notes)
+	}
+}
+
+func TestSessionHistoryOfUnknownSession(t *testing.T) {
+	svc := newTestService(t)
+	session := mustCreateSession(t, svc, "Physics", at(8, 0), at(9, 0))
+	for _, tt := range []struct{ user, id string }{{testUser, "missing"}, {otherUser, session.ID}} {
+		if _, err := svc.SessionHistory(context.Background(), tt.user, tt.id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("history of %s for %s: err = %v, want ErrNotFound", tt.id, tt.user, err)
+		}
+	}
+}
+
+func TestRevertSession(t *testing.T) {
+	tests := []struct {
+		name     string
+		revision func(history, otherHistory []SessionRevision) string
+		user     string
+		stale    bool
+		want     error
+	}{
+		{
+			name:     "to the created state",
+			revision: func(history, _ []SessionRevision) string { return history[0].ID },
+			user:     testUser,
+		},
+		{
+			name:     "with a stale version",
+			revision: func(history, _ []SessionRevision) string { return history[0].ID },
+			user:     testUser,
+			stale:    true,
+			want:     ErrVersionConflict,
+		},
+		{
+			name: "to another session's revision",
+			revision: func(_, otherHistory []SessionRevision) string {
+				return otherHistory[0].ID
+			},
+			user: testUser,
+			want: ErrRevisionNotFound,
+		},
+		{
+			name:     "by another user",
+			revision: func(history, _ []SessionRevision) string { return history[0].ID },
+			user:     otherUser,
+			want:     ErrRevisionNotFound,
+		},
+		{
+			name:     "to a deleted state",
+			revision: func(history, _ []SessionRevision) string { return history[2].ID },
+			user:     testUser,
+			want:     ErrRevisionDeleted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			ctx := context.Background()
+			session := mustCreateSession(t, svc, "Physics", at(8, 0), at(9, 0))
+			other := mustCreateSession(t, svc, "Math", at(10, 0), at(11, 0))
+			if _, err := svc.PatchSession(ctx, testUser, session.ID, []byte(`{"subject":"Math","notes":"reviewed"}`), 0); err != nil {
+				t.Fatal(err)
+			}
+			if err := svc.DeleteSession(ctx, testUser, session.ID, 0); err != nil {
+				t.Fatal(err)
+			}
+			restored, err := svc.RestoreSession(ctx, testUser, session.ID, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			history, _ := svc.SessionHistory(ctx, testUser, session.ID)
+			otherHistory, _ := svc.SessionHistory(ctx, testUser, other.ID)
+
+			version := restored.Version
+			if tt.stale {
+				version--
+			}
+			reverted, err := svc.RevertSession(ctx, tt.user, session.ID, tt.revision(history, otherHistory), version)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				return
+			}
+			if reverted.Subject != "Physics" || reverted.SubjectID != session.SubjectID || reverted.Notes != "" {
+				t.Fatalf("reverted to %s (%s) with notes %q, want the created state", reverted.Subject, reverted.SubjectID, reverted.Notes)
+			}
+			if reverted.Version != restored.Version+1 {
+				t.Fatalf("version = %d, want %d", reverted.Version, restored.Version+1)
+			}
+			history, _ = svc.SessionHistory(ctx, testUser, session.ID)
+			if last := history[len(history)-1]; last.Action != RevisionRevert || last.Before.Subject != "Math" {
+				t.Fatalf("last revision = %s from %s, want a revert from Math", last.Action, last.Before.Subject)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package study
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	minRating = 1
+	maxRating = 5
+)
+
+// RatingInsights summarises the optional session ratings. Only sessions
+// with at least one rating are included. BySubject is keyed by subject ID,
+// like ProgressSummary, and SubjectNames gives each ID's current name.
+type RatingInsights struct {
+	Overall      RatingAverages            `json:"overall"`
+	BySubject    map[string]RatingAverages `json:"bySubject"`
+	SubjectNames map[string]string         `json:"subjectNames"`
+	// ByHour is keyed by the local start hour, "00" to "23".
+	ByHour map[string]RatingAverages `json:"byHour"`
+	// ByWeekday is keyed by lower-case day name, e.g. "monday".
+	ByWeekday   map[string]RatingAverages `json:"byWeekday"`
+	LengthFocus LengthFocus               `json:"lengthFocus"`
+}
+
+// RatingAverages holds the mean of each rating over the sessions that
+// recorded it; a rating nobody gave is null.
+type RatingAverages struct {
+	SessionCount int      `json:"sessionCount"`
+	Focus        *float64 `json:"focus"`
+	Mood         *float64 `json:"mood"`
+	Energy       *float64 `json:"energy"`
+	Difficulty   *float64 `json:"difficulty"`
+}
+
+// LengthFocus relates session length to focus. Correlation is Pearson's r
+// between duration and focus, null with fewer than three sessions or when
+// either value never varies.
+type LengthFocus struct {
+	SampleSize  int                 `json:"sampleSize"`
+	Correlation *float64            `json:"correlation"`
+	ByLength    []LengthFocusBucket `json:"byLength"`
+}
+
+// LengthFocusBucket is the average focus of sessions lasting at least
+// MinMinutes and less than MaxMinutes; the last bucket has no MaxMinutes.
+type LengthFocusBucket struct {
+	MinMinutes   int      `json:"minMinutes"`
+	MaxMinutes   *int     `json:"maxMinutes,omitempty"`
+	SessionCount int      `json:"sessionCount"`
+	AverageFocus *float64 `json:"averageFocus"`
+}
+
+var lengthBucketBounds = []int{0, 30, 60, 90, 120}
+
+// RatingInsights aggregates the user's session ratings by subject, local
+// hour of day and day of week, and relates focus to session length.
+func (s *Service) RatingInsights(ctx context.Context, userID string) (RatingInsights, error) {
+	sessions, err := s.sessions.List(userID, SessionFilter{})
+	if err != nil {
+		return RatingInsights{}, err
+	}
+	subjectKey, err := s.subjectKeyer(ctx, userID, 0)
+	if err != nil {
+		return RatingInsights{}, err
+	}
+
+	var overall ratingAccumulator
+	bySubject := make(map[string]*ratingAccumulator)
+	subjectNames := make(map[string]string)
+	byHour := make(map[string]*ratingAccumulator)
+	byWeekday := make(map[string]*ratingAccumulator)
+	buckets := make([]ratingAccumulator, len(lengthBucketBounds))
+	var lengths, focuses []float64
+
+	for _, session := range sessions {
+		if !hasRatings(session) {
+			continue
+		}
+		start := session.StartTime.In(time.Local)
+		overall.add(session)
+		subject, name := subjectKey(session)
+		accumulate(bySubject, subject, session)
+		subjectNames[subject] = name
+		accumulate(byHour, fmt.Sprintf("%02d", start.Hour()), session)
+		accumulate(byWeekday, strings.ToLower(start.Weekday().String()), session)
+
+		if session.Focus != nil {
+			buckets[lengthBucket(session.DurationMinutes)].add(session)
+			lengths = append(lengths, float64(session.DurationMinutes))
+			focuses = append(focuses, float64(*session.Focus))
+		}
+	}
+
+	insights := RatingInsights{
+		Overall:      overall.averages(),
+		BySubject:    averagesOf(bySubject),
+		SubjectNames: subjectNames,
+		ByHour:       averagesOf(byHour),
+		ByWeekday:    averagesOf(byWeekday),
+		LengthFocus: LengthFocus{
+			SampleSize:  len(focuses),
+			Correlation: pearson(lengths, focuses),
+			ByLength:    make([]LengthFocusBucket, len(lengthBucketBounds)),
+		},
+	}
+	for i, bound := range lengthBucketBounds {
+		bucket := LengthFocusBucket{
+			MinMinutes:   bound,
+			SessionCount: buckets[i].counts[0],
+			AverageFocus: buckets[i].average(0),
+		}
+		if i+1 < len(lengthBucketBounds) {
+			upper := lengthBucketBounds[i+1]
+			bucket.MaxMinutes = &upper
+		}
+		insights.LengthFocus.ByLength[i] = bucket
+	}
+
+	return insights, nil
+}
+
+func hasRatings(session StudySession) bool {
+	return session.Focus != nil || session.Mood != nil || session.Energy != nil || session.Difficulty != nil
+}
+
+func lengthBucket(minutes int) int {
+	for i := len(lengthBucketBounds) - 1; i > 0; i-- {
+		if minutes >= lengthBucketBounds[i] {
+			return i
+		}
+	}
+	return 0
+}
+
+// ratingAccumulator sums focus, mood, energy and difficulty, in that order.
+type ratingAccumulator struct {
+	sessions int
+	sums     [4]int
+	counts   [4]int
+}
+
+func (a *ratingAccumulator) add(session StudySession) {
+	a.sessions++
+	for i, rating := range []*int{session.Focus, session.Mood, session.Energy, session.Difficulty} {
+		if rating != nil {
+			a.sums[i] += *rating
+			a.counts[i]++
+		}
+	}
+}
+
+func (a *ratingAccumulator) average(i int) *float64 {
+	if a.counts[i] == 0 {
+		return nil
+	}
+	return round2(float64(a.sums[i]) / float64(a.counts[i]))
+}
+
+func (a *ratingAccumulator) averages() RatingAverages {
+	return RatingAverages{
+		SessionCount: a.sessions,
+		Focus:        a.average(0),
+		Mood:         a.average(1),
+		Energy:       a.average(2),
+		Difficulty:   a.average(3),
+	}
+}
+
+func accumulate(groups map[string]*ratingAccumulator, key string, session StudySession) {
+	acc, ok := groups[key]
+	if !ok {
+		acc = &ratingAccumulator{}
+		groups[key] = acc
+	}
+	acc.add(session)
+}
+
+func averagesOf(groups map[string]*ratingAccumulator) map[string]RatingAverages {
+	out := make(map[string]RatingAverages, len(groups))
+	for key, acc := range groups {
+		out[key] = acc.averages()
+	}
+	return out
+}
+
+func pearson(xs, ys []float64) *float64 {
+	n := float64(len(xs))
+	if len(xs) < 3 {
+		return nil
+	}
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return nil
+	}
+	return round2(cov / math.Sqrt(varX*varY))
+}
+
+func round2(v float64) *float64 {
+	r := math.Round(v*100) / 100
+	return &r
+}
@@ -16,19 +16,41 @@ type StudySession struct {
 	DurationMinutes int       `json:"durationMinutes"`
 	CreatedAt       time.Time `json:"createdAt"`
 	LastUpdated     time.Time `json:"lastUpdated"`
+	Version         int       `json:"version"`
+	Tags            []string  `json:"tags"`
+	// Mode is manual or pomodoro. Segments are optional for manual sessions
+	// and required for Pomodoro ones; a session with segments takes its
+	// times from them and its duration from their focused time.
+	Mode     string    `json:"mode"`
+	Segments []Segment `json:"segments,omitempty"`
+	// Optional self-assessments from 1 (lowest) to 5 (highest).
+	Focus      *int `json:"focus,omitempty"`
+	Mood       *int `json:"mood,omitempty"`
+	Energy     *int `json:"energy,omitempty"`
+	Difficulty *int `json:"difficulty,omitempty"`
+	// DeletedAt is set on deleted sessions, which only appear in sync results.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	// OverlapsWith lists the sessions a write overlaps under the warn
+	// policy. It is only set on write responses and never stored.
+	OverlapsWith []string `json:"overlapsWith,omitempty"`
 }
 
-// ProgressSummary aggregates stats for a user's study activity.
+// ProgressSummary aggregates stats for a user's study activity. BySubject,
+// here and in DailyTrend, is keyed by subject ID so renames never split it;
+// SubjectNames gives each ID's current name. ByTag counts a session's
+// minutes once for each of its tags.
 type ProgressSummary struct {
-	TotalMinutes          int            `json:"totalMinutes"`
-	SessionCount          int            `json:"sessionCount"`
-	AverageSessionMinutes float64        `json:"averageSessionMinutes"`
-	TodayMinutes          int            `json:"todayMinutes"`
-	WeekMinutes           int            `json:"weekMinutes"`
-	MonthMinutes          int            `json:"monthMinutes"`
-	BySubject             map[string]int `json:"bySubject"`
-	DailyTrend            []DailyStat    `json:"dailyTrend"`
-	StreakDays            int            `json:"streakDays"`
+	TotalMinutes          int               `json:"totalMinutes"`
+	SessionCount          int               `json:"sessionCount"`
+	AverageSessionMinutes float64           `json:"averageSessionMinutes"`
+	TodayMinutes          int               `json:"todayMinutes"`
+	WeekMinutes           int               `json:"weekMinutes"`
+	MonthMinutes          int               `json:"monthMinutes"`
+	BySubject             map[string]int    `json:"bySubject"`
+	SubjectNames          map[string]string `json:"subjectNames"`
+	ByTag                 map[string]int    `json:"byTag"`
+	DailyTrend            []DailyStat       `json:"dailyTrend"`
+	StreakDays            int               `json:"streakDays"`
 }
 
 // DailyStat represents aggregated stats for a single calendar day.
@@ -0,0 +1,203 @@
+package study
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Overlap resolutions.
+const (
+	ResolveMerge = "merge"
+	ResolveSplit = "split"
+)
+
+// OverlapError rejects a session that overlaps others under the reject
+// policy. It matches ErrSessionOverlap.
+type OverlapError struct {
+	SessionIDs []string
+}
+
+func (e *OverlapError) Error() string {
+	return fmt.Sprintf("%s (%d sessions)", ErrSessionOverlap, len(e.SessionIDs))
+}
+
+func (e *OverlapError) Unwrap() error {
+	return ErrSessionOverlap
+}
+
+// OverlapPair is two sessions that cover some of the same time.
+type OverlapPair struct {
+	First          StudySession `json:"first"`
+	Second         StudySession `json:"second"`
+	OverlapMinutes int          `json:"overlapMinutes"`
+}
+
+// ResolveOverlapRequest names the two sessions of a pair and how to resolve
+// them.
+type ResolveOverlapRequest struct {
+	SessionIDs []string `json:"sessionIds"`
+	Action     string   `json:"action"`
+}
+
+// checkOverlaps applies the user's overlap policy to a prepared session and
+// returns the IDs of the sessions it overlaps when the policy is warn.
+// Sessions with segments only occupy their segments, breaks included, so a
+// session logged in a gap between segments does not count.
+func (s *Service) checkOverlaps(ctx context.Context, session StudySession) ([]string, error) {
+	prefs, err := s.Preferences(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if prefs.OverlapPolicy == OverlapAllow {
+		return nil, nil
+	}
+
+	candidates, err := s.sessions.Overlapping(session.UserID, session.ID, session.StartTime, session.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, candidate := range candidates {
+		if overlapDuration(session, candidate) > 0 {
+			ids = append(ids, candidate.ID)
+		}
+	}
+	if len(ids) > 0 && prefs.OverlapPolicy == OverlapReject {
+		return nil, &OverlapError{SessionIDs: ids}
+	}
+	return ids, nil
+}
+
+// ListOverlaps returns every pair of the user's sessions that overlap,
+// earliest first.
+func (s *Service) ListOverlaps(ctx context.Context, userID string) ([]OverlapPair, error) {
+	sessions, err := s.sessions.List(userID, SessionFilter{})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+
+	pairs := []OverlapPair{}
+	for i, first := range sessions {
+		for _, second := range sessions[i+1:] {
+			if !second.StartTime.Before(first.EndTime) {
+				break
+			}
+			if overlap := overlapDuration(first, second); overlap > 0 {
+				pairs = append(pairs, OverlapPair{
+					First:          first,
+					Second:         second,
+					OverlapMinutes: int(overlap.Round(time.Minute) / time.Minute),
+				})
+			}
+		}
+	}
+	return pairs, nil
+}
+
+// ResolveOverlap merges an overlapping pair into one session, or splits the
+// one that starts first around the other. It returns the resulting sessions.
+func (s *Service) ResolveOverlap(ctx context.Context, userID string, req ResolveOverlapRequest) ([]StudySession, error) {
+	if len(req.SessionIDs) != 2 || req.SessionIDs[0] == req.SessionIDs[1] {
+		return nil, ErrInvalidResolution
+	}
+
+	var result []StudySession
+	err := s.atomically(ctx, func(scoped *Service) error {
+		first, err := scoped.sessions.Get(userID, req.SessionIDs[0])
+		if err != nil {
+			return err
+		}
+		second, err := scoped.sessions.Get(userID, req.SessionIDs[1])
+		if err != nil {
+			return err
+		}
+		if overlapDuration(first, second) <= 0 {
+			return ErrSessionsDoNotOverlap
+		}
+
+		switch req.Action {
+		case ResolveMerge:
+			merged, err := scoped.mergeSessions(ctx, userID, []StudySession{first, second})
+			if err != nil {
+				return err
+			}
+			result = []StudySession{merged}
+		case ResolveSplit:
+			result, err = scoped.splitAround(ctx, userID, first, second)
+			return err
+		default:
+			return ErrInvalidResolution
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "session overlap resolved", "userId", userID, "action", req.Action, "sessions", req.SessionIDs)
+	return result, nil
+}
+
+// mergeSessions combines sessions into the earliest one and deletes the
+// rest. Every write is recorded as a merge revision.
+func (s *Service) mergeSessions(ctx context.Context, userID string, sessions []StudySession) (StudySession, error) {
+	merged := combineSessions(sessions)
+	for _, session := range sessions {
+		if session.ID == merged.ID {
+			continue
+		}
+		if err := s.sessions.Delete(userID, session.ID, session.Version); err != nil {
+			return StudySession{}, err
+		}
+		if err := s.recordRevision(userID, RevisionMerge, &session, nil); err != nil {
+			return StudySession{}, err
+		}
+	}
+	return s.updateSession(ctx, userID, merged, RevisionMerge)
+}
+
+// splitAround removes other's span from whichever session starts first
+// (the longer one on a tie), leaving up to two parts of it either side.
+func (s *Service) splitAround(ctx context.Context, userID string, a, b StudySession) ([]StudySession, error) {
+	outer, inner := a, b
+	if b.StartTime.Before(a.StartTime) || (b.StartTime.Equal(a.StartTime) && b.EndTime.After(a.EndTime)) {
+		outer, inner = b, a
+	}
+	before, hasBefore := sliceSession(outer, outer.StartTime, inner.StartTime)
+	after, hasAfter := sliceSession(outer, inner.EndTime, outer.EndTime)
+
+	var parts []StudySession
+	switch {
+	case hasBefore && hasAfter:
+		updated, err := s.updateSession(ctx, userID, before, RevisionSplit)
+		if err != nil {
+			return nil, err
+		}
+		after.ID = ""
+		created, err := s.createSession(ctx, userID, after, RevisionSplit)
+		if err != nil {
+			return nil, err
+		}
+		parts = []StudySession{updated, inner, created}
+	case hasBefore || hasAfter:
+		part := before
+		if hasAfter {
+			part = after
+		}
+		updated, err := s.updateSession(ctx, userID, part, RevisionSplit)
+		if err != nil {
+			return nil, err
+		}
+		parts = []StudySession{updated, inner}
+	default:
+		return nil, ErrCannotSplit
+	}
+	sort.SliceStable(parts, func(i, j int) bool {
+		return parts[i].StartTime.Before(parts[j].StartTime)
+	})
+	return parts, nil
+}
@@ -0,0 +1,163 @@
+package study
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestOverlapPolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       string
+		session      StudySession
+		wantErr      error
+		wantOverlaps []int
+	}{
+		{
+			name:         "warn reports the overlapped sessions",
+			policy:       OverlapWarn,
+			session:      StudySession{Subject: "Math", StartTime: at(8, 30), EndTime: at(10, 30)},
+			wantOverlaps: []int{0, 1},
+		},
+		{
+			name:    "reject refuses the session",
+			policy:  OverlapReject,
+			session: StudySession{Subject: "Math", StartTime: at(8, 30), EndTime: at(9, 30)},
+			wantErr: ErrSessionOverlap,
+		},
+		{
+			name:    "allow stores it silently",
+			policy:  OverlapAllow,
+			session: StudySession{Subject: "Math", StartTime: at(8, 30), EndTime: at(9, 30)},
+		},
+		{
+			name:    "touching sessions do not overlap",
+			policy:  OverlapReject,
+			session: StudySession{Subject: "Math", StartTime: at(9, 0), EndTime: at(10, 0)},
+		},
+		{
+			name:    "a gap between segments is free",
+			policy:  OverlapReject,
+			session: StudySession{Subject: "Math", StartTime: at(11, 30), EndTime: at(12, 0)},
+		},
+		{
+			name:   "segments occupy only their own time",
+			policy: OverlapReject,
+			session: StudySession{Subject: "Math", Segments: []Segment{
+				{StartTime: at(9, 0), EndTime: at(10, 0)},
+				{StartTime: at(10, 45), EndTime: at(11, 0)},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			ctx := context.Background()
+			existing := []StudySession{
+				mustCreateSession(t, svc, "Physics", at(8, 0), at(9, 0)),
+			}
+			segmented, err := svc.CreateSession(ctx, testUser, StudySession{Subject: "Physics", Segments: []Segment{
+				{StartTime: at(10, 0), EndTime: at(10, 40)},
+				{StartTime: at(12, 0), EndTime: at(12, 30)},
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			existing = append(existing, segmented)
+			if _, err := svc.SavePreferences(ctx, testUser, Preferences{OverlapPolicy: tt.policy}); err != nil {
+				t.Fatal(err)
+			}
+
+			created, err := svc.CreateSession(ctx, testUser, tt.session)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			var want []string
+			for _, i := range tt.wantOverlaps {
+				want = append(want, existing[i].ID)
+			}
+			if !reflect.DeepEqual(created.OverlapsWith, want) {
+				t.Fatalf("overlapsWith = %v, want %v", created.OverlapsWith, want)
+			}
+		})
+	}
+}
+
+func TestOverlapIgnoresTheSessionItself(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	if _, err := svc.SavePreferences(ctx, testUser, Preferences{OverlapPolicy: OverlapReject}); err != nil {
+		t.Fatal(err)
+	}
+	session := mustCreateSession(t, svc, "Physics", at(8, 0), at(9, 0))
+	if _, err := svc.PatchSession(ctx, testUser, session.ID, []byte(`{"endTime":"2026-10-01T09:30:00Z"}`), 0); err != nil {
+		t.Fatalf("extending a lone session: %v", err)
+	}
+}
+
+func TestSavePreferencesRejectsUnknownPolicy(t *testing.T) {
+	svc := newTestService(t)
+	if _, err := svc.SavePreferences(context.Background(), testUser, Preferences{OverlapPolicy: "ignore"}); !errors.Is(err, ErrInvalidOverlapPolicy) {
+		t.Fatalf("err = %v, want ErrInvalidOverlapPolicy", err)
+	}
+}
+
+func TestResolveOverlap(t *testing.T) {
+	tests := []struct {
+		name      string
+		action    string
+		wantErr   error
+		wantSpans [][2]int
+	}{
+		{name: "merge", action: ResolveMerge, wantSpans: [][2]int{{8 * 60, 11 * 60}}},
+		{name: "split", action: ResolveSplit, wantSpans: [][2]int{{8 * 60, 9 * 60}, {9 * 60, 10 * 60}, {10 * 60, 11 * 60}}},
+		{name: "unknown action", action: "ignore", wantErr: ErrInvalidResolution},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			ctx := context.Background()
+			outer := mustCreateSession(t, svc, "Physics", at(8, 0), at(11, 0))
+			inner := mustCreateSession(t, svc, "Physics", at(9, 0), at(10, 0))
+
+			pairs, err := svc.ListOverlaps(ctx, testUser)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pairs) != 1 || pairs[0].First.ID != outer.ID || pairs[0].OverlapMinutes != 60 {
+				t.Fatalf("overlaps = %+v, want one 60-minute pair starting with %s", pairs, outer.ID)
+			}
+
+			result, err := svc.ResolveOverlap(ctx, testUser, ResolveOverlapRequest{SessionIDs: []string{outer.ID, inner.ID}, Action: tt.action})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(result) != len(tt.wantSpans) {
+				t.Fatalf("got %d sessions, want %d", len(result), len(tt.wantSpans))
+			}
+			for i, span := range tt.wantSpans {
+				start, end := at(0, span[0]), at(0, span[1])
+				if !result[i].StartTime.Equal(start) || !result[i].EndTime.Equal(end) {
+					t.Errorf("session %d spans %v - %v, want %v - %v", i, result[i].StartTime, result[i].EndTime, start, end)
+				}
+			}
+			if pairs, _ := svc.ListOverlaps(ctx, testUser); len(pairs) != 0 {
+				t.Fatalf("%d overlaps left after resolving", len(pairs))
+			}
+			if len(result) > 1 {
+				again := ResolveOverlapRequest{SessionIDs: []string{result[0].ID, result[1].ID}, Action: tt.action}
+				if _, err := svc.ResolveOverlap(ctx, testUser, again); !errors.Is(err, ErrSessionsDoNotOverlap) {
+					t.Fatalf("resolving again: err = %v, want ErrSessionsDoNotOverlap", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package study
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Default Pomodoro timings, used until a user saves their own.
+const (
+	defaultWorkMinutes       = 25
+	defaultShortBreakMinutes = 5
+	defaultLongBreakMinutes  = 15
+	defaultLongBreakEvery    = 4
+)
+
+// breakTolerance is how far a break may stray from its planned length, as a
+// fraction of it, and still count as taken properly.
+const breakTolerance = 0.25
+
+// PomodoroSettings are a user's interval lengths. LongBreakEvery is the
+// number of focus intervals between long breaks.
+type PomodoroSettings struct {
+	WorkMinutes       int        `json:"workMinutes"`
+	ShortBreakMinutes int        `json:"shortBreakMinutes"`
+	LongBreakMinutes  int        `json:"longBreakMinutes"`
+	LongBreakEvery    int        `json:"longBreakEvery"`
+	UpdatedAt         *time.Time `json:"updatedAt,omitempty"`
+}
+
+// PomodoroStats summarises the user's Pomodoro sessions. BreakCompliance is
+// the share of breaks that were due and taken within 25% of their planned
+// length; a focus interval followed directly by another counts as a skipped
+// break. It is null when no break was due.
+type PomodoroStats struct {
+	Sessions           int      `json:"sessions"`
+	CompletedPomodoros int      `json:"completedPomodoros"`
+	Interruptions      int      `json:"interruptions"`
+	FocusMinutes       int      `json:"focusMinutes"`
+	BreakMinutes       int      `json:"breakMinutes"`
+	BreaksTaken        int      `json:"breaksTaken"`
+	BreaksSkipped      int      `json:"breaksSkipped"`
+	CompliantBreaks    int      `json:"compliantBreaks"`
+	BreakCompliance    *float64 `json:"breakCompliance"`
+}
+
+func defaultPomodoroSettings() PomodoroSettings {
+	return PomodoroSettings{
+		WorkMinutes:       defaultWorkMinutes,
+		ShortBreakMinutes: defaultShortBreakMinutes,
+		LongBreakMinutes:  defaultLongBreakMinutes,
+		LongBreakEvery:    defaultLongBreakEvery,
+	}
+}
+
+func (p PomodoroSettings) breakMinutes(kind string) int {
+	if kind == SegmentLongBreak {
+		return p.LongBreakMinutes
+	}
+	return p.ShortBreakMinutes
+}
+
+func (p PomodoroSettings) validate() error {
+	switch {
+	case p.WorkMinutes < 1 || p.WorkMinutes > 180,
+		p.ShortBreakMinutes < 1 || p.ShortBreakMinutes > 60,
+		p.LongBreakMinutes < 1 || p.LongBreakMinutes > 60,
+		p.LongBreakEvery < 1 || p.LongBreakEvery > 12:
+		return ErrInvalidPomodoroSettings
+	}
+	return nil
+}
+
+// PomodoroSettings returns the user's interval lengths, or the defaults if
+// they never saved any.
+func (s *Service) PomodoroSettings(ctx context.Context, userID string) (PomodoroSettings, error) {
+	if s.pomodoro == nil {
+		return defaultPomodoroSettings(), nil
+	}
+	settings, found, err := s.pomodoro.Get(userID)
+	if err != nil {
+		return PomodoroSettings{}, err
+	}
+	if !found {
+		return defaultPomodoroSettings(), nil
+	}
+	return settings, nil
+}
+
+// SavePomodoroSettings replaces the user's interval lengths. Existing
+// sessions keep the planned lengths they were recorded with.
+func (s *Service) SavePomodoroSettings(ctx context.Context, userID string, settings PomodoroSettings) (PomodoroSettings, error) {
+	if err := settings.validate(); err != nil {
+		return PomodoroSettings{}, err
+	}
+	now := time.Now().UTC()
+	settings.UpdatedAt = &now
+	if err := s.pomodoro.Save(userID, settings); err != nil {
+		return PomodoroSettings{}, err
+	}
+	s.logger.InfoContext(ctx, "pomodoro settings saved", "userId", userID)
+	return settings, nil
+}
+
+// PomodoroStats aggregates the segments of the user's Pomodoro sessions.
+func (s *Service) PomodoroStats(ctx context.Context, userID string) (PomodoroStats, error) {
+	sessions, err := s.sessions.List(userID, SessionFilter{})
+	if err != nil {
+		return PomodoroStats{}, err
+	}
+
+	var stats PomodoroStats
+	var focused, rested time.Duration
+	for _, session := range sessions {
+		if session.Mode != ModePomodoro {
+			continue
+		}
+		stats.Sessions++
+
+		for i, seg := range session.Segments {
+			if seg.Kind != SegmentFocus {
+				stats.BreaksTaken++
+				rested += seg.EndTime.Sub(seg.StartTime)
+				planned := float64(seg.PlannedMinutes)
+				if math.Abs(seg.Minutes()-planned) <= planned*breakTolerance {
+					stats.CompliantBreaks++
+				}
+				continue
+			}
+
+			focused += seg.EndTime.Sub(seg.StartTime)
+			if seg.Interrupted {
+				stats.Interruptions++
+			} else {
+				stats.CompletedPomodoros++
+			}
+			if i+1 < len(session.Segments) && session.Segments[i+1].Kind == SegmentFocus {
+				stats.BreaksSkipped++
+			}
+		}
+	}
+
+	stats.FocusMinutes = int(math.Round(focused.Minutes()))
+	stats.BreakMinutes = int(math.Round(rested.Minutes()))
+	if due := stats.BreaksTaken + stats.BreaksSkipped; due > 0 {
+		stats.BreakCompliance = round2(float64(stats.CompliantBreaks) / float64(due))
+	}
+	return stats, nil
+}
@@ -0,0 +1,84 @@
+package study
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"studytracker/internal/platform/database"
+)
+
+// SQLPomodoroSettingsRepository persists Pomodoro settings to SQLite.
+type SQLPomodoroSettingsRepository struct {
+	db        database.Executor
+	useDollar bool
+}
+
+// NewSQLPomodoroSettingsRepository returns a PomodoroSettingsRepository backed by db.
+func NewSQLPomodoroSettingsRepository(db *sql.DB) *SQLPomodoroSettingsRepository {
+	return &SQLPomodoroSettingsRepository{
+		db:        db,
+		useDollar: database.UsesDollarPlaceholders(db),
+	}
+}
+
+func (r *SQLPomodoroSettingsRepository) Get(userID string) (PomodoroSettings, bool, error) {
+	const query = `
+		SELECT work_minutes, short_break_minutes, long_break_minutes, long_break_every, updated_at
+		FROM pomodoro_settings
+		WHERE user_id = ?;
+	`
+
+	var settings PomodoroSettings
+	var updated time.Time
+	err := r.db.QueryRowContext(context.Background(), r.rebind(query), userID).Scan(
+		&settings.WorkMinutes,
+		&settings.ShortBreakMinutes,
+		&settings.LongBreakMinutes,
+		&settings.LongBreakEvery,
+		&updated,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PomodoroSettings{}, false, nil
+		}
+		return PomodoroSettings{}, false, err
+	}
+	updated = updated.UTC()
+	settings.UpdatedAt = &updated
+	return settings, true, nil
+}
+
+func (r *SQLPomodoroSettingsRepository) Save(userID string, settings PomodoroSettings) error {
+	const query = `
+		INSERT INTO pomodoro_settings (user_id, work_minutes, short_break_minutes, long_break_minutes, long_break_every, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			work_minutes = excluded.work_minutes,
+			short_break_minutes = excluded.short_break_minutes,
+			long_break_minutes = excluded.long_break_minutes,
+			long_break_every = excluded.long_break_every,
+			updated_at = excluded.updated_at;
+	`
+
+	updated := time.Now().UTC()
+	if settings.UpdatedAt != nil {
+		updated = settings.UpdatedAt.UTC()
+	}
+	_, err := r.db.ExecContext(
+		context.Background(),
+		r.rebind(query),
+		userID,
+		settings.WorkMinutes,
+		settings.ShortBreakMinutes,
+		settings.LongBreakMinutes,
+		settings.LongBreakEvery,
+		updated,
+	)
+	return err
+}
+
+func (r *SQLPomodoroSettingsRepository) rebind(query string) string {
+	return database.Rebind(query, r.useDollar)
+}
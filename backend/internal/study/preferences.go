@@ -0,0 +1,56 @@
+package study
+
+import (
+	"context"
+	"time"
+)
+
+// Overlap policies decide what happens when a session overlaps another.
+const (
+	OverlapReject = "reject"
+	OverlapWarn   = "warn"
+	OverlapAllow  = "allow"
+)
+
+// Preferences are per-user study settings.
+type Preferences struct {
+	// OverlapPolicy is reject, warn (the default) or allow.
+	OverlapPolicy string     `json:"overlapPolicy"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+}
+
+func defaultPreferences() Preferences {
+	return Preferences{OverlapPolicy: OverlapWarn}
+}
+
+// Preferences returns the user's preferences, or the defaults if they never
+// saved any.
+func (s *Service) Preferences(ctx context.Context, userID string) (Preferences, error) {
+	if s.preferences == nil {
+		return defaultPreferences(), nil
+	}
+	prefs, found, err := s.preferences.Get(userID)
+	if err != nil {
+		return Preferences{}, err
+	}
+	if !found {
+		return defaultPreferences(), nil
+	}
+	return prefs, nil
+}
+
+// SavePreferences replaces the user's preferences.
+func (s *Service) SavePreferences(ctx context.Context, userID string, prefs Preferences) (Preferences, error) {
+	switch prefs.OverlapPolicy {
+	case OverlapReject, OverlapWarn, OverlapAllow:
+	default:
+		return Preferences{}, ErrInvalidOverlapPolicy
+	}
+	now := time.Now().UTC()
+	prefs.UpdatedAt = &now
+	if err := s.preferences.Save(userID, prefs); err != nil {
+		return Preferences{}, err
+	}
+	s.logger.InfoContext(ctx, "study preferences saved", "userId", userID, "overlapPolicy", prefs.OverlapPolicy)
+	return prefs, nil
+}
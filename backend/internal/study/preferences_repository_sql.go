@@ -0,0 +1,62 @@
+package study
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"studytracker/internal/platform/database"
+)
+
+// SQLPreferencesRepository persists study preferences to SQLite.
+type SQLPreferencesRepository struct {
+	db        database.Executor
+	useDollar bool
+}
+
+// NewSQLPreferencesRepository returns a PreferencesRepository backed by db.
+func NewSQLPreferencesRepository(db *sql.DB) *SQLPreferencesRepository {
+	return &SQLPreferencesRepository{
+		db:        db,
+		useDollar: database.UsesDollarPlaceholders(db),
+	}
+}
+
+func (r *SQLPreferencesRepository) Get(userID string) (Preferences, bool, error) {
+	const query = `SELECT overlap_policy, updated_at FROM study_preferences WHERE user_id = ?;`
+
+	var prefs Preferences
+	var updated time.Time
+	err := r.db.QueryRowContext(context.Background(), r.rebind(query), userID).Scan(&prefs.OverlapPolicy, &updated)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Preferences{}, false, nil
+		}
+		return Preferences{}, false, err
+	}
+	updated = updated.UTC()
+	prefs.UpdatedAt = &updated
+	return prefs, true, nil
+}
+
+func (r *SQLPreferencesRepository) Save(userID string, prefs Preferences) error {
+	const query = `
+		INSERT INTO study_preferences (user_id, overlap_policy, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			overlap_policy = excluded.overlap_policy,
+			updated_at = excluded.updated_at;
+	`
+
+	updated := time.Now().UTC()
+	if prefs.UpdatedAt != nil {
+		updated = prefs.UpdatedAt.UTC()
+	}
+	_, err := r.db.ExecContext(context.Background(), r.rebind(query), userID, prefs.OverlapPolicy, updated)
+	return err
+}
+
+func (r *SQLPreferencesRepository) rebind(query string) string {
+	return database.Rebind(query, r.useDollar)
+}
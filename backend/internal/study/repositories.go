@@ -1,19 +1,100 @@
 package study
 
+import (
+	"context"
+	"time"
+)
+
 // SessionRepository defines persistence behavior for study sessions.
+// Update and Delete only apply when the stored version equals the expected
+// one (session.Version or version); zero skips the check. Deleted rows are
+// kept as tombstones, visible through ChangedSince and ListDeleted, until
+// Restore revives them or PurgeDeleted removes them for good.
 type SessionRepository interface {
 	Create(session StudySession) (StudySession, error)
 	Update(session StudySession) (StudySession, error)
-	Delete(userID, id string) error
-	List(userID string) ([]StudySession, error)
+	Delete(userID, id string, version int) error
+	List(userID string, filter SessionFilter) ([]StudySession, error)
+	Overlapping(userID, excludeID string, start, end time.Time) ([]StudySession, error)
+	Get(userID, id string) (StudySession, error)
+	ChangedSince(userID string, since time.Time) ([]StudySession, error)
+	ListDeleted(userID string) ([]StudySession, error)
+	Restore(userID, id string, version int) error
+	PurgeDeleted(before time.Time) (int64, error)
 }
 
-// SubjectRepository defines persistence for subjects.
+// SubjectRepository defines persistence for subjects, with the same version
+// and tombstone semantics as SessionRepository.
 type SubjectRepository interface {
 	Create(subject Subject) (Subject, error)
 	Update(subject Subject) (Subject, error)
-	Delete(userID, id string) error
+	Move(userID, id, parentID string, version int) (Subject, error)
+	SetArchived(userID, id string, archived bool, version int) (Subject, error)
+	Merge(userID, sourceID, targetID string) error
+	ReassignSessions(userID, fromID string, to Subject, at time.Time) error
+	Delete(userID, id string, version int) error
 	List(userID string) ([]Subject, error)
 	Get(userID, id string) (Subject, error)
 	GetByName(userID, name string) (Subject, error)
+	ChangedSince(userID string, since time.Time) ([]Subject, error)
+	ListDeleted(userID string) ([]Subject, error)
+	Restore(userID, id string, version int) error
+	// PurgeDeleted skips subjects still referenced by sessions.
+	PurgeDeleted(before time.Time) (int64, error)
+}
+
+// RevisionRepository stores the append-only session history.
+type RevisionRepository interface {
+	Append(revisions ...SessionRevision) error
+	ListForSession(userID, sessionID string) ([]SessionRevision, error)
+	Get(userID, id string) (SessionRevision, error)
+}
+
+// TagRepository manages a user's tags. Sessions attach tags by name through
+// SessionRepository; Rename and Merge bump the version of every live session
+// they affect.
+type TagRepository interface {
+	// List returns tags whose name starts with prefix, most used first.
+	List(userID, prefix string, limit int) ([]Tag, error)
+	Get(userID, id string) (Tag, error)
+	Rename(userID, id, name string) error
+	Merge(userID, sourceID, targetID string) error
+}
+
+// PomodoroSettingsRepository stores per-user Pomodoro timings. Get reports
+// found=false when the user never saved any.
+type PomodoroSettingsRepository interface {
+	Get(userID string) (settings PomodoroSettings, found bool, err error)
+	Save(userID string, settings PomodoroSettings) error
+}
+
+// PreferencesRepository stores per-user study preferences. Get reports
+// found=false when the user never saved any.
+type PreferencesRepository interface {
+	Get(userID string) (prefs Preferences, found bool, err error)
+	Save(userID string, prefs Preferences) error
+}
+
+// Repositories groups the stores the service works with.
+type Repositories struct {
+	Sessions    SessionRepository
+	Subjects    SubjectRepository
+	Revisions   RevisionRepository
+	Tags        TagRepository
+	Pomodoro    PomodoroSettingsRepository
+	Preferences PreferencesRepository
+}
+
+// TxRepositories are repositories bound to a single transaction.
+type TxRepositories struct {
+	Repositories
+	// Savepoint runs fn and undoes only its writes when it fails, leaving
+	// the rest of the transaction usable.
+	Savepoint func(ctx context.Context, fn func() error) error
+}
+
+// Transactor runs work inside a database transaction.
+type Transactor interface {
+	// InTx commits when fn returns nil and rolls back otherwise.
+	InTx(ctx context.Context, fn func(tx TxRepositories) error) error
 }
@@ -0,0 +1,298 @@
+package study
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SplitRequest cuts a session in two at At. FirstSubject and SecondSubject
+// optionally move either part to another subject.
+type SplitRequest struct {
+	At            time.Time `json:"at"`
+	FirstSubject  string    `json:"firstSubject,omitempty"`
+	SecondSubject string    `json:"secondSubject,omitempty"`
+}
+
+// MergeRequest names the sessions to combine.
+type MergeRequest struct {
+	SessionIDs []string `json:"sessionIds"`
+}
+
+// SplitSession cuts a session at req.At. The original keeps the first part
+// and a new session gets the second; both are recorded as split revisions.
+// Merging the parts back undoes it; reverting the original alone would leave
+// the second part counted twice. A non-zero version must match the stored
+// one.
+func (s *Service) SplitSession(ctx context.Context, userID, id string, req SplitRequest, version int) ([]StudySession, error) {
+	var parts []StudySession
+	err := s.atomically(ctx, func(scoped *Service) error {
+		session, err := scoped.sessions.Get(userID, id)
+		if err != nil {
+			return err
+		}
+		if version != 0 && version != session.Version {
+			return ErrVersionConflict
+		}
+		at := req.At.UTC()
+		first, ok := sliceSession(session, session.StartTime, at)
+		if !ok || !at.Before(session.EndTime) {
+			return ErrInvalidSplitPoint
+		}
+		second, ok := sliceSession(session, at, session.EndTime)
+		if !ok {
+			return ErrInvalidSplitPoint
+		}
+		resubject(&first, req.FirstSubject)
+		resubject(&second, req.SecondSubject)
+		second.ID = ""
+
+		updated, err := scoped.updateSession(ctx, userID, first, RevisionSplit)
+		if err != nil {
+			return err
+		}
+		created, err := scoped.createSession(ctx, userID, second, RevisionSplit)
+		if err != nil {
+			return err
+		}
+		parts = []StudySession{updated, created}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "study session split", "userId", userID, "sessionId", id, "newSessionId", parts[1].ID)
+	return parts, nil
+}
+
+// MergeSessions combines adjacent sessions of one subject into the earliest
+// of them, concatenating notes and reflections. The others move to the
+// trash; every write is recorded as a merge revision, so reverting the
+// merged session and restoring the others undoes it.
+func (s *Service) MergeSessions(ctx context.Context, userID string, req MergeRequest) (StudySession, error) {
+	ids := slices.Compact(slices.Sorted(slices.Values(req.SessionIDs)))
+	if len(ids) < 2 || len(ids) != len(req.SessionIDs) {
+		return StudySession{}, ErrMergeTooFew
+	}
+
+	var merged StudySession
+	err := s.atomically(ctx, func(scoped *Service) error {
+		sessions := make([]StudySession, len(ids))
+		for i, id := range ids {
+			session, err := scoped.sessions.Get(userID, id)
+			if err != nil {
+				return err
+			}
+			if i > 0 && session.SubjectID != sessions[0].SubjectID {
+				return ErrMergeSubjectMismatch
+			}
+			sessions[i] = session
+		}
+		if err := scoped.checkAdjacent(userID, sessions); err != nil {
+			return err
+		}
+
+		var err error
+		merged, err = scoped.mergeSessions(ctx, userID, sessions)
+		return err
+	})
+	if err != nil {
+		return StudySession{}, err
+	}
+	s.logger.InfoContext(ctx, "study sessions merged", "userId", userID, "sessionId", merged.ID, "merged", len(ids))
+	return merged, nil
+}
+
+// checkAdjacent fails unless no other session starts or ends in the gaps
+// between sessions, taken in start order.
+func (s *Service) checkAdjacent(userID string, sessions []StudySession) error {
+	sorted := slices.Clone(sessions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartTime.Before(sorted[j].StartTime)
+	})
+	included := make(map[string]bool, len(sorted))
+	for _, session := range sorted {
+		included[session.ID] = true
+	}
+
+	end := sorted[0].EndTime
+	for _, next := range sorted[1:] {
+		if next.StartTime.After(end) {
+			between, err := s.sessions.Overlapping(userID, "", end, next.StartTime)
+			if err != nil {
+				return err
+			}
+			for _, other := range between {
+				if !included[other.ID] {
+					return ErrSessionsNotAdjacent
+				}
+			}
+		}
+		end = later(end, next.EndTime)
+	}
+	return nil
+}
+
+// resubject moves a session part to another subject, leaving it unchanged
+// when subject is blank.
+func resubject(session *StudySession, subject string) {
+	if strings.TrimSpace(subject) == "" {
+		return
+	}
+	session.Subject = subject
+	session.SubjectID = ""
+	session.SubjectColor = ""
+}
+
+// occupied returns the intervals a session covers: its segments, breaks
+// included, or its whole span when it has none.
+func occupied(session StudySession) []Segment {
+	if len(session.Segments) > 0 {
+		return session.Segments
+	}
+	return []Segment{{Kind: SegmentFocus, StartTime: session.StartTime, EndTime: session.EndTime}}
+}
+
+// overlapDuration is how long two sessions cover the same time.
+func overlapDuration(a, b StudySession) time.Duration {
+	var total time.Duration
+	for _, x := range occupied(a) {
+		for _, y := range occupied(b) {
+			start, end := later(x.StartTime, y.StartTime), earlier(x.EndTime, y.EndTime)
+			if end.After(start) {
+				total += end.Sub(start)
+			}
+		}
+	}
+	return total
+}
+
+// sliceSession returns the part of session that falls within [from, to), or
+// false when nothing does. The part keeps the session's ID and fields.
+func sliceSession(session StudySession, from, to time.Time) (StudySession, bool) {
+	var clipped []Segment
+	for _, seg := range occupied(session) {
+		seg.StartTime, seg.EndTime = later(seg.StartTime, from), earlier(seg.EndTime, to)
+		if seg.EndTime.After(seg.StartTime) {
+			clipped = append(clipped, seg)
+		}
+	}
+	if len(clipped) == 0 {
+		return StudySession{}, false
+	}
+
+	part := session
+	part.Tags = slices.Clone(session.Tags)
+	part.StartTime = clipped[0].StartTime
+	part.EndTime = clipped[len(clipped)-1].EndTime
+	part.Segments = []Segment{}
+	if len(session.Segments) > 0 {
+		part.Segments = clipped
+	}
+	return part, true
+}
+
+// combineSessions folds sessions into the one that starts first. Notes and
+// reflections are concatenated, tags united and each rating taken from the
+// first session that has one. Segments are kept when none overlap;
+// otherwise the covered time is united into focus segments of a manual
+// session, so nothing is counted twice.
+func combineSessions(sessions []StudySession) StudySession {
+	sessions = slices.Clone(sessions)
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+
+	merged := sessions[0]
+	var notes, reflections []string
+	var tags []string
+	var segments []Segment
+	for _, session := range sessions {
+		notes = appendUnique(notes, session.Notes)
+		reflections = appendUnique(reflections, session.Reflection)
+		for _, tag := range session.Tags {
+			tags = appendUnique(tags, tag)
+		}
+		merged.Focus = firstRating(merged.Focus, session.Focus)
+		merged.Mood = firstRating(merged.Mood, session.Mood)
+		merged.Energy = firstRating(merged.Energy, session.Energy)
+		merged.Difficulty = firstRating(merged.Difficulty, session.Difficulty)
+		if session.Mode != merged.Mode {
+			merged.Mode = ModeManual
+		}
+		segments = append(segments, occupied(session)...)
+	}
+	merged.Notes = strings.Join(notes, "\n\n")
+	merged.Reflection = strings.Join(reflections, "\n\n")
+	merged.Tags = tags
+	if merged.Tags == nil {
+		merged.Tags = []string{}
+	}
+
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].StartTime.Before(segments[j].StartTime)
+	})
+	if overlapping(segments) {
+		merged.Mode = ModeManual
+		segments = unite(segments)
+	}
+	merged.StartTime = segments[0].StartTime
+	merged.EndTime = segments[len(segments)-1].EndTime
+	merged.Segments = segments
+	if len(segments) == 1 && merged.Mode == ModeManual && !segments[0].Interrupted {
+		merged.Segments = []Segment{}
+	}
+	return merged
+}
+
+func overlapping(sorted []Segment) bool {
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].StartTime.Before(sorted[i-1].EndTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// unite merges sorted segments into the focus intervals they cover.
+func unite(sorted []Segment) []Segment {
+	var out []Segment
+	for _, seg := range sorted {
+		if n := len(out); n > 0 && !seg.StartTime.After(out[n-1].EndTime) {
+			out[n-1].EndTime = later(out[n-1].EndTime, seg.EndTime)
+			continue
+		}
+		out = append(out, Segment{Kind: SegmentFocus, StartTime: seg.StartTime, EndTime: seg.EndTime})
+	}
+	return out
+}
+
+func appendUnique(values []string, value string) []string {
+	if strings.TrimSpace(value) == "" || slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+func firstRating(current, candidate *int) *int {
+	if current != nil {
+		return current
+	}
+	return candidate
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,236 @@
+package study
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestSliceSession(t *testing.T) {
+	manual := StudySession{ID: "s1", StartTime: at(8, 0), EndTime: at(10, 0), Tags: []string{"exam"}, Segments: []Segment{}}
+	segmented := StudySession{ID: "s2", StartTime: at(8, 0), EndTime: at(10, 0), Segments: []Segment{
+		{Kind: SegmentFocus, StartTime: at(8, 0), EndTime: at(8, 50)},
+		{Kind: SegmentFocus, StartTime: at(9, 10), EndTime: at(10, 0)},
+	}}
+	tests := []struct {
+		name      string
+		session   StudySession
+		from, to  int
+		wantOK    bool
+		wantStart int
+		wantEnd   int
+		wantSegs  int
+	}{
+		{name: "manual part", session: manual, from: 9 * 60, to: 12 * 60, wantOK: true, wantStart: 9 * 60, wantEnd: 10 * 60},
+		{name: "outside the session", session: manual, from: 11 * 60, to: 12 * 60},
+		{name: "segments are clipped", session: segmented, from: 8*60 + 30, to: 9*60 + 30, wantOK: true, wantStart: 8*60 + 30, wantEnd: 9*60 + 30, wantSegs: 2},
+		{name: "the part shrinks to its segments", session: segmented, from: 8*60 + 30, to: 9 * 60, wantOK: true, wantStart: 8*60 + 30, wantEnd: 8*60 + 50, wantSegs: 1},
+		{name: "a pause holds nothing", session: segmented, from: 8*60 + 50, to: 9*60 + 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			part, ok := sliceSession(tt.session, at(0, tt.from), at(0, tt.to))
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if part.ID != tt.session.ID || !part.StartTime.Equal(at(0, tt.wantStart)) || !part.EndTime.Equal(at(0, tt.wantEnd)) {
+				t.Fatalf("part %s spans %v - %v", part.ID, part.StartTime, part.EndTime)
+			}
+			if len(part.Segments) != tt.wantSegs {
+				t.Fatalf("got %d segments, want %d", len(part.Segments), tt.wantSegs)
+			}
+		})
+	}
+
+	// The part's tags must not alias the original's.
+	part, _ := sliceSession(manual, at(8, 0), at(9, 0))
+	part.Tags[0] = "changed"
+	if manual.Tags[0] != "exam" {
+		t.Fatal("slicing shared the tags slice")
+	}
+}
+
+func TestCombineSessions(t *testing.T) {
+	tests := []struct {
+		name     string
+		sessions []StudySession
+		check    func(t *testing.T, merged StudySession)
+	}{
+		{
+			name: "keeps the earliest and turns gaps into pauses",
+			sessions: []StudySession{
+				{ID: "late", Mode: ModeManual, StartTime: at(10, 0), EndTime: at(11, 0), Notes: "b", Tags: []string{"exam", "ch2"}, Focus: intPtr(4)},
+				{ID: "early", Mode: ModeManual, StartTime: at(8, 0), EndTime: at(9, 0), Notes: "a", Tags: []string{"exam"}, Mood: intPtr(3)},
+			},
+			check: func(t *testing.T, merged StudySession) {
+				if merged.ID != "early" || !merged.StartTime.Equal(at(8, 0)) || !merged.EndTime.Equal(at(11, 0)) {
+					t.Fatalf("merged = %s spanning %v - %v", merged.ID, merged.StartTime, merged.EndTime)
+				}
+				if len(merged.Segments) != 2 {
+					t.Fatalf("got %d segments, want the two sessions with a pause between", len(merged.Segments))
+				}
+				if merged.Notes != "a\n\nb" || !reflect.DeepEqual(merged.Tags, []string{"exam", "ch2"}) {
+					t.Fatalf("notes = %q, tags = %v", merged.Notes, merged.Tags)
+				}
+				if merged.Focus == nil || *merged.Focus != 4 || merged.Mood == nil || *merged.Mood != 3 {
+					t.Fatalf("ratings = focus %v, mood %v", merged.Focus, merged.Mood)
+				}
+			},
+		},
+		{
+			name: "counts overlapping time once",
+			sessions: []StudySession{
+				{ID: "a", Mode: ModeManual, StartTime: at(8, 0), EndTime: at(9, 30)},
+				{ID: "b", Mode: ModeManual, StartTime: at(9, 0), EndTime: at(10, 0)},
+			},
+			check: func(t *testing.T, merged StudySession) {
+				if len(merged.Segments) != 0 || !merged.EndTime.Equal(at(10, 0)) {
+					t.Fatalf("merged = %v - %v with %d segments, want one 08:00-10:00 block", merged.StartTime, merged.EndTime, len(merged.Segments))
+				}
+			},
+		},
+		{
+			name: "mixed modes become manual",
+			sessions: []StudySession{
+				{ID: "a", Mode: ModePomodoro, StartTime: at(8, 0), EndTime: at(8, 25), Segments: []Segment{{Kind: SegmentFocus, StartTime: at(8, 0), EndTime: at(8, 25)}}},
+				{ID: "b", Mode: ModeManual, StartTime: at(8, 30), EndTime: at(9, 0)},
+			},
+			check: func(t *testing.T, merged StudySession) {
+				if merged.Mode != ModeManual || len(merged.Segments) != 2 {
+					t.Fatalf("mode = %s with %d segments", merged.Mode, len(merged.Segments))
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, combineSessions(tt.sessions))
+		})
+	}
+}
+
+func TestSplitSession(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      SplitRequest
+		stale    bool
+		wantErr  error
+		wantSubj [2]string
+	}{
+		{name: "keeps the subject", req: SplitRequest{At: at(9, 0)}, wantSubj: [2]string{"Physics", "Physics"}},
+		{name: "moves the second part", req: SplitRequest{At: at(9, 0), SecondSubject: "Math"}, wantSubj: [2]string{"Physics", "Math"}},
+		{name: "at the start", req: SplitRequest{At: at(8, 0)}, wantErr: ErrInvalidSplitPoint},
+		{name: "at the end", req: SplitRequest{At: at(10, 0)}, wantErr: ErrInvalidSplitPoint},
+		{name: "with a stale version", req: SplitRequest{At: at(9, 0)}, stale: true, wantErr: ErrVersionConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			ctx := context.Background()
+			session := mustCreateSession(t, svc, "Physics", at(8, 0), at(10, 0))
+			version := session.Version
+			if tt.stale {
+				version++
+			}
+
+			parts, err := svc.SplitSession(ctx, testUser, session.ID, tt.req, version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(parts) != 2 || parts[0].ID != session.ID || parts[1].ID == session.ID {
+				t.Fatalf("parts = %+v, want the original and a new session", parts)
+			}
+			for i, part := range parts {
+				if part.Subject != tt.wantSubj[i] || part.DurationMinutes != 60 {
+					t.Errorf("part %d = %s for %d minutes, want %s for 60", i, part.Subject, part.DurationMinutes, tt.wantSubj[i])
+				}
+			}
+			for _, part := range parts {
+				history, _ := svc.SessionHistory(ctx, testUser, part.ID)
+				if last := history[len(history)-1]; last.Action != RevisionSplit {
+					t.Errorf("last revision of %s = %s, want split", part.ID, last.Action)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeSessions(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     func(a, b, c StudySession) []string
+		wantErr error
+	}{
+		{name: "adjacent sessions", ids: func(a, b, _ StudySession) []string { return []string{b.ID, a.ID} }},
+		{name: "a single session", ids: func(a, _, _ StudySession) []string { return []string{a.ID} }, wantErr: ErrMergeTooFew},
+		{name: "a repeated session", ids: func(a, b, _ StudySession) []string { return []string{a.ID, a.ID, b.ID} }, wantErr: ErrMergeTooFew},
+		{name: "different subjects", ids: func(_, b, c StudySession) []string { return []string{b.ID, c.ID} }, wantErr: ErrMergeSubjectMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			ctx := context.Background()
+			a := mustCreateSession(t, svc, "Physics", at(8, 0), at(9, 0))
+			b := mustCreateSession(t, svc, "Physics", at(9, 30), at(10, 0))
+			c := mustCreateSession(t, svc, "Math", at(11, 0), at(12, 0))
+
+			merged, err := svc.MergeSessions(ctx, testUser, MergeRequest{SessionIDs: tt.ids(a, b, c)})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if merged.ID != a.ID || merged.DurationMinutes != 90 || len(merged.Segments) != 2 {
+				t.Fatalf("merged = %s for %d minutes in %d segments, want %s for 90 in 2", merged.ID, merged.DurationMinutes, len(merged.Segments), a.ID)
+			}
+			trash, _ := svc.ListTrash(ctx, testUser)
+			if len(trash.Sessions) != 1 || trash.Sessions[0].ID != b.ID {
+				t.Fatalf("trash = %+v, want %s", trash.Sessions, b.ID)
+			}
+		})
+	}
+}
+
+func TestMergeSessionsRequiresAdjacency(t *testing.T) {
+	svc := newTestService(t)
+	a := mustCreateSession(t, svc, "Physics", at(8, 0), at(9, 0))
+	mustCreateSession(t, svc, "Math", at(9, 15), at(9, 45))
+	c := mustCreateSession(t, svc, "Physics", at(10, 0), at(11, 0))
+
+	_, err := svc.MergeSessions(context.Background(), testUser, MergeRequest{SessionIDs: []string{a.ID, c.ID}})
+	if !errors.Is(err, ErrSessionsNotAdjacent) {
+		t.Fatalf("err = %v, want ErrSessionsNotAdjacent", err)
+	}
+}
+
+func TestSplitThenMergeRestoresTheSession(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	session := mustCreateSession(t, svc, "Physics", at(8, 0), at(10, 0))
+	parts, err := svc.SplitSession(ctx, testUser, session.ID, SplitRequest{At: at(9, 0)}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := svc.MergeSessions(ctx, testUser, MergeRequest{SessionIDs: []string{parts[0].ID, parts[1].ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.ID != session.ID || merged.DurationMinutes != 120 || !merged.StartTime.Equal(at(8, 0)) || !merged.EndTime.Equal(at(10, 0)) {
+		t.Fatalf("merged = %s spanning %v - %v for %d minutes, want the original 08:00-10:00", merged.ID, merged.StartTime, merged.EndTime, merged.DurationMinutes)
+	}
+	sessions, _ := svc.ListSessions(ctx, testUser, SessionFilter{})
+	if len(sessions) != 1 {
+		t.Fatalf("%d live sessions after undoing the split, want 1", len(sessions))
+	}
+}
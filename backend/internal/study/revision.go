@@ -0,0 +1,29 @@
+package study
+
+import "time"
+
+// Revision actions.
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+	RevisionRevert  = "revert"
+	RevisionMerge   = "merge"
+	RevisionSplit   = "split"
+)
+
+// SessionRevision is one entry in a session's append-only history. Before is
+// nil for creates and restores, After is nil for deletes. Revision is the session
+// version the change produced.
+type SessionRevision struct {
+	ID        string        `json:"id"`
+	SessionID string        `json:"sessionId"`
+	UserID    string        `json:"userId"`
+	ActorID   string        `json:"actorId"`
+	Revision  int           `json:"revision"`
+	Action    string        `json:"action"`
+	Before    *StudySession `json:"before"`
+	After     *StudySession `json:"after"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
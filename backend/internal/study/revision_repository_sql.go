@@ -0,0 +1,183 @@
+package study
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"studytracker/internal/platform/database"
+)
+
+// SQLRevisionRepository persists session revisions.
+type SQLRevisionRepository struct {
+	db        database.Executor
+	useDollar bool
+}
+
+// NewSQLRevisionRepository returns a RevisionRepository backed by db.
+func NewSQLRevisionRepository(db *sql.DB) *SQLRevisionRepository {
+	return &SQLRevisionRepository{
+		db:        db,
+		useDollar: database.UsesDollarPlaceholders(db),
+	}
+}
+
+// appendChunk bounds the rows per INSERT so the statement stays within
+// SQLite's limit on bound parameters.
+const appendChunk = 100
+
+// Append stores revisions with one multi-row INSERT per appendChunk rows.
+func (r *SQLRevisionRepository) Append(revisions ...SessionRevision) error {
+	const (
+		insert = `
+		INSERT INTO session_revisions (
+			id, session_id, user_id, actor_id, revision, action,
+			before_snapshot, after_snapshot, created_at
+		) VALUES `
+		row = `(?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	)
+
+	for len(revisions) > 0 {
+		chunk := revisions[:min(len(revisions), appendChunk)]
+		revisions = revisions[len(chunk):]
+
+		rows := make([]string, len(chunk))
+		args := make([]any, 0, len(chunk)*9)
+		for i, revision := range chunk {
+			before, err := snapshot(revision.Before)
+			if err != nil {
+				return err
+			}
+			after, err := snapshot(revision.After)
+			if err != nil {
+				return err
+			}
+			rows[i] = row
+			args = append(args,
+				revision.ID,
+				revision.SessionID,
+				revision.UserID,
+				revision.ActorID,
+				revision.Revision,
+				revision.Action,
+				before,
+				after,
+				revision.CreatedAt.UTC(),
+			)
+		}
+
+		query := insert + strings.Join(rows, ", ") + ";"
+		if _, err := r.db.ExecContext(context.Background(), r.rebind(query), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const revisionColumns = `id, session_id, user_id, actor_id, revision, action,
+		       before_snapshot, after_snapshot, created_at`
+
+func (r *SQLRevisionRepository) ListForSession(userID, sessionID string) ([]SessionRevision, error) {
+	const query = `
+		SELECT ` + revisionColumns + `
+		FROM session_revisions
+		WHERE session_id = ? AND user_id = ?
+		ORDER BY revision ASC, created_at ASC;
+	`
+
+	rows, err := r.db.QueryContext(context.Background(), r.rebind(query), sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []SessionRevision
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *SQLRevisionRepository) Get(userID, id string) (SessionRevision, error) {
+	const query = `
+		SELECT ` + revisionColumns + `
+		FROM session_revisions
+		WHERE id = ? AND user_id = ?;
+	`
+
+	revision, err := scanRevision(r.db.QueryRowContext(context.Background(), r.rebind(query), id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return SessionRevision{}, ErrRevisionNotFound
+		}
+		return SessionRevision{}, err
+	}
+	return revision, nil
+}
+
+func scanRevision(row rowScanner) (SessionRevision, error) {
+	var revision SessionRevision
+	var before, after sql.NullString
+	var created time.Time
+
+	if err := row.Scan(
+		&revision.ID,
+		&revision.SessionID,
+		&revision.UserID,
+		&revision.ActorID,
+		&revision.Revision,
+		&revision.Action,
+		&before,
+		&after,
+		&created,
+	); err != nil {
+		return SessionRevision{}, err
+	}
+
+	var err error
+	if revision.Before, err = parseSnapshot(before); err != nil {
+		return SessionRevision{}, err
+	}
+	if revision.After, err = parseSnapshot(after); err != nil {
+		return SessionRevision{}, err
+	}
+	revision.CreatedAt = created.UTC()
+	return revision, nil
+}
+
+// Snapshots are stored as the session's JSON encoding.
+func snapshot(session *StudySession) (sql.NullString, error) {
+	if session == nil {
+		return sql.NullString{}, nil
+	}
+	raw, err := json.Marshal(session)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(raw), Valid: true}, nil
+}
+
+func parseSnapshot(raw sql.NullString) (*StudySession, error) {
+	if !raw.Valid {
+		return nil, nil
+	}
+	var session StudySession
+	if err := json.Unmarshal([]byte(raw.String), &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *SQLRevisionRepository) rebind(query string) string {
+	return database.Rebind(query, r.useDollar)
+}
@@ -0,0 +1,104 @@
+package study
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Session modes.
+const (
+	ModeManual   = "manual"
+	ModePomodoro = "pomodoro"
+)
+
+// Segment kinds. Only focus segments count towards a session's duration.
+const (
+	SegmentFocus      = "focus"
+	SegmentShortBreak = "short_break"
+	SegmentLongBreak  = "long_break"
+)
+
+// Segment is one interval of a session; gaps between segments are pauses.
+// PlannedMinutes is the Pomodoro interval length the user was aiming for.
+// Interrupted marks a focus segment that was cut short, as reported by the
+// client or, for Pomodoro sessions, because it ended before its planned
+// length.
+type Segment struct {
+	Kind           string    `json:"kind"`
+	StartTime      time.Time `json:"startTime"`
+	EndTime        time.Time `json:"endTime"`
+	PlannedMinutes int       `json:"plannedMinutes,omitempty"`
+	Interrupted    bool      `json:"interrupted"`
+}
+
+// Minutes returns the segment's length in fractional minutes.
+func (seg Segment) Minutes() float64 {
+	return seg.EndTime.Sub(seg.StartTime).Minutes()
+}
+
+// prepareSegments sorts and validates segments and returns the focused
+// minutes they cover. Kind defaults to focus. For Pomodoro sessions, pomodoro
+// holds the user's settings, used to fill in planned lengths. Errors name
+// segments by their position in the request, before sorting.
+func prepareSegments(segments []Segment, pomodoro *PomodoroSettings) (int, error) {
+	for i := range segments {
+		seg := &segments[i]
+		seg.StartTime = seg.StartTime.UTC()
+		seg.EndTime = seg.EndTime.UTC()
+		if seg.Kind == "" {
+			seg.Kind = SegmentFocus
+		}
+		switch {
+		case seg.StartTime.IsZero():
+			return 0, &SegmentError{Index: i, Field: "startTime", Reason: "is required"}
+		case !seg.EndTime.After(seg.StartTime):
+			return 0, &SegmentError{Index: i, Field: "endTime", Reason: "must be after startTime"}
+		case seg.PlannedMinutes < 0:
+			return 0, &SegmentError{Index: i, Field: "plannedMinutes", Reason: "must not be negative"}
+		case seg.Kind != SegmentFocus && seg.Kind != SegmentShortBreak && seg.Kind != SegmentLongBreak:
+			return 0, &SegmentError{Index: i, Field: "kind", Reason: "must be focus, short_break or long_break"}
+		}
+	}
+
+	order := make([]int, len(segments))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return segments[order[a]].StartTime.Before(segments[order[b]].StartTime)
+	})
+	sorted := make([]Segment, len(segments))
+	for i, at := range order {
+		sorted[i] = segments[at]
+	}
+	copy(segments, sorted)
+
+	var focused time.Duration
+	for i := range segments {
+		seg := &segments[i]
+		if i > 0 && seg.StartTime.Before(segments[i-1].EndTime) {
+			return 0, &SegmentError{Index: order[i], Field: "startTime", Reason: "overlaps another segment"}
+		}
+
+		switch seg.Kind {
+		case SegmentFocus:
+			if pomodoro != nil {
+				if seg.PlannedMinutes == 0 {
+					seg.PlannedMinutes = pomodoro.WorkMinutes
+				}
+				if seg.Minutes() < float64(seg.PlannedMinutes) {
+					seg.Interrupted = true
+				}
+			}
+			focused += seg.EndTime.Sub(seg.StartTime)
+		case SegmentShortBreak, SegmentLongBreak:
+			if pomodoro != nil && seg.PlannedMinutes == 0 {
+				seg.PlannedMinutes = pomodoro.breakMinutes(seg.Kind)
+			}
+			seg.Interrupted = false
+		}
+	}
+
+	return int(math.Ceil(focused.Minutes())), nil
+}
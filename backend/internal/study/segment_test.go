@@ -0,0 +1,192 @@
+package study
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPrepareSegments(t *testing.T) {
+	pomodoro := &PomodoroSettings{WorkMinutes: 25, ShortBreakMinutes: 5, LongBreakMinutes: 15, LongBreakEvery: 4}
+	tests := []struct {
+		name        string
+		segments    []Segment
+		pomodoro    *PomodoroSettings
+		wantFocused int
+		wantErr     *SegmentError
+		check       func(t *testing.T, segments []Segment)
+	}{
+		{
+			name: "counts focus and skips breaks and pauses",
+			segments: []Segment{
+				{StartTime: at(8, 0), EndTime: at(8, 25)},
+				{Kind: SegmentShortBreak, StartTime: at(8, 25), EndTime: at(8, 30)},
+				{StartTime: at(8, 40), EndTime: at(9, 5)},
+			},
+			wantFocused: 50,
+			check: func(t *testing.T, segments []Segment) {
+				if segments[0].Kind != SegmentFocus {
+					t.Fatalf("kind = %q, want focus by default", segments[0].Kind)
+				}
+			},
+		},
+		{
+			name: "sorts by start time",
+			segments: []Segment{
+				{StartTime: at(9, 0), EndTime: at(9, 30)},
+				{StartTime: at(8, 0), EndTime: at(8, 30)},
+			},
+			wantFocused: 60,
+			check: func(t *testing.T, segments []Segment) {
+				if !segments[0].StartTime.Equal(at(8, 0)) {
+					t.Fatalf("first segment starts at %v, want 08:00", segments[0].StartTime)
+				}
+			},
+		},
+		{
+			name: "fills planned lengths and marks short focus as interrupted",
+			segments: []Segment{
+				{StartTime: at(8, 0), EndTime: at(8, 20)},
+				{Kind: SegmentLongBreak, StartTime: at(8, 20), EndTime: at(8, 25), Interrupted: true},
+			},
+			pomodoro:    pomodoro,
+			wantFocused: 20,
+			check: func(t *testing.T, segments []Segment) {
+				if segments[0].PlannedMinutes != 25 || !segments[0].Interrupted {
+					t.Fatalf("focus segment = %+v, want planned 25 and interrupted", segments[0])
+				}
+				if segments[1].PlannedMinutes != 15 || segments[1].Interrupted {
+					t.Fatalf("break segment = %+v, want planned 15 and not interrupted", segments[1])
+				}
+			},
+		},
+		{
+			name:     "missing start",
+			segments: []Segment{{StartTime: at(8, 0), EndTime: at(8, 30)}, {EndTime: at(9, 0)}},
+			wantErr:  &SegmentError{Index: 1, Field: "startTime"},
+		},
+		{
+			name:     "ends before it starts",
+			segments: []Segment{{StartTime: at(8, 30), EndTime: at(8, 30)}},
+			wantErr:  &SegmentError{Index: 0, Field: "endTime"},
+		},
+		{
+			name:     "negative planned length",
+			segments: []Segment{{StartTime: at(8, 0), EndTime: at(8, 30), PlannedMinutes: -1}},
+			wantErr:  &SegmentError{Index: 0, Field: "plannedMinutes"},
+		},
+		{
+			name:     "unknown kind",
+			segments: []Segment{{Kind: "nap", StartTime: at(8, 0), EndTime: at(8, 30)}},
+			wantErr:  &SegmentError{Index: 0, Field: "kind"},
+		},
+		{
+			name: "overlap names the later segment as sent",
+			segments: []Segment{
+				{StartTime: at(8, 20), EndTime: at(8, 50)},
+				{StartTime: at(8, 0), EndTime: at(8, 30)},
+			},
+			wantErr: &SegmentError{Index: 0, Field: "startTime"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			focused, err := prepareSegments(tt.segments, tt.pomodoro)
+			if tt.wantErr != nil {
+				var segErr *SegmentError
+				if !errors.As(err, &segErr) || segErr.Index != tt.wantErr.Index || segErr.Field != tt.wantErr.Field {
+					t.Fatalf("err = %v, want segments[%d].%s", err, tt.wantErr.Index, tt.wantErr.Field)
+				}
+				if !errors.Is(err, ErrInvalidSegment) {
+					t.Fatalf("err = %v does not wrap ErrInvalidSegment", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepareSegments: %v", err)
+			}
+			if focused != tt.wantFocused {
+				t.Fatalf("focused = %d, want %d", focused, tt.wantFocused)
+			}
+			if tt.check != nil {
+				tt.check(t, tt.segments)
+			}
+		})
+	}
+}
+
+func TestPatchSegmentedSession(t *testing.T) {
+	tests := []struct {
+		name      string
+		patch     string
+		wantErr   error
+		wantStart time.Time
+		wantEnd   time.Time
+		wantMode  string
+		wantSegs  int
+	}{
+		{
+			name:      "notes keep the segments",
+			patch:     `{"notes":"reviewed"}`,
+			wantStart: at(8, 0), wantEnd: at(9, 0), wantMode: ModePomodoro, wantSegs: 3,
+		},
+		{
+			name:      "new times replace the segments",
+			patch:     `{"startTime":"2026-10-01T10:00:00Z","endTime":"2026-10-01T11:30:00Z"}`,
+			wantStart: at(10, 0), wantEnd: at(11, 30), wantMode: ModeManual,
+		},
+		{
+			name:      "a new end time keeps the start",
+			patch:     `{"endTime":"2026-10-01T10:00:00Z"}`,
+			wantStart: at(8, 0), wantEnd: at(10, 0), wantMode: ModeManual,
+		},
+		{
+			name:      "new segments override the times",
+			patch:     `{"startTime":"2026-10-01T07:00:00Z","segments":[{"startTime":"2026-10-01T12:00:00Z","endTime":"2026-10-01T12:25:00Z"}]}`,
+			wantStart: at(12, 0), wantEnd: at(12, 25), wantMode: ModePomodoro, wantSegs: 1,
+		},
+		{
+			name:    "pomodoro mode still needs segments",
+			patch:   `{"mode":"pomodoro","endTime":"2026-10-01T10:00:00Z"}`,
+			wantErr: ErrSegmentsRequired,
+		},
+		{
+			name:    "new times are validated",
+			patch:   `{"endTime":"2026-10-01T07:00:00Z"}`,
+			wantErr: ErrInvalidTiming,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			ctx := context.Background()
+			session, err := svc.CreateSession(ctx, testUser, StudySession{
+				Subject: "Physics",
+				Mode:    ModePomodoro,
+				Segments: []Segment{
+					{StartTime: at(8, 0), EndTime: at(8, 25)},
+					{Kind: SegmentShortBreak, StartTime: at(8, 25), EndTime: at(8, 30)},
+					{StartTime: at(8, 30), EndTime: at(9, 0)},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			patched, err := svc.PatchSession(ctx, testUser, session.ID, []byte(tt.patch), 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !patched.StartTime.Equal(tt.wantStart) || !patched.EndTime.Equal(tt.wantEnd) {
+				t.Fatalf("times = %v - %v, want %v - %v", patched.StartTime, patched.EndTime, tt.wantStart, tt.wantEnd)
+			}
+			if patched.Mode != tt.wantMode || len(patched.Segments) != tt.wantSegs {
+				t.Fatalf("mode = %s with %d segments, want %s with %d", patched.Mode, len(patched.Segments), tt.wantMode, tt.wantSegs)
+			}
+		})
+	}
+}
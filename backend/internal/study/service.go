@@ -1,13 +1,17 @@
 package study
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"math"
 	"strings"
 	"time"
+
+	"studytracker/internal/platform/mergepatch"
 )
 
 const (
@@ -15,69 +19,265 @@ const (
 	trendDays = 14
 )
 
+// Recorder receives domain events for instrumentation.
+type Recorder interface {
+	SessionLogged(minutes int)
+}
+
+type nopRecorder struct{}
+
+func (nopRecorder) SessionLogged(int) {}
+
+// Config tunes the study service.
+type Config struct {
+	// TrashRetention is how long deleted items stay restorable.
+	TrashRetention time.Duration
+}
+
 // Service contains the business logic for study tracking.
 type Service struct {
-	sessions SessionRepository
-	subjects SubjectRepository
+	sessions       SessionRepository
+	subjects       SubjectRepository
+	revisions      RevisionRepository
+	tags           TagRepository
+	pomodoro       PomodoroSettingsRepository
+	preferences    PreferencesRepository
+	tx             Transactor
+	inTx           bool
+	trashRetention time.Duration
+	recorder       Recorder
+	logger         *slog.Logger
 }
 
-// NewService constructs a service with the provided repositories.
-func NewService(sessionRepo SessionRepository, subjectRepo SubjectRepository) *Service {
-	return &Service{
-		sessions: sessionRepo,
-		subjects: subjectRepo,
+// NewService constructs a service with the provided repositories. tx makes
+// each session write and its revision atomic and backs multi-item operations
+// such as batches; recorder may be nil.
+func NewService(repos Repositories, tx Transactor, cfg Config, recorder Recorder, logger *slog.Logger) *Service {
+	if cfg.TrashRetention == 0 {
+		cfg.TrashRetention = 30 * 24 * time.Hour
+	}
+	if recorder == nil {
+		recorder = nopRecorder{}
 	}
+	s := &Service{
+		tx:             tx,
+		trashRetention: cfg.TrashRetention,
+		recorder:       recorder,
+		logger:         logger,
+	}
+	s.bind(repos)
+	return s
+}
+
+func (s *Service) bind(repos Repositories) {
+	s.sessions = repos.Sessions
+	s.subjects = repos.Subjects
+	s.revisions = repos.Revisions
+	s.tags = repos.Tags
+	s.pomodoro = repos.Pomodoro
+	s.preferences = repos.Preferences
 }
 
 // Study session operations ----------------------------------------------------
 
 // CreateSession stores a new study session, generating an ID when missing.
-func (s *Service) CreateSession(userID string, session StudySession) (StudySession, error) {
+func (s *Service) CreateSession(ctx context.Context, userID string, session StudySession) (StudySession, error) {
+	// The subject is resolved from its name; see prepareSession.
+	session.SubjectID = ""
+	return s.createSession(ctx, userID, session, RevisionCreate)
+}
+
+func (s *Service) createSession(ctx context.Context, userID string, session StudySession, action string) (StudySession, error) {
 	session.UserID = userID
-	log.Printf("CreateSession: user=%s subject=%s", userID, session.Subject)
-	if err := s.prepareSession(&session, true); err != nil {
-		log.Printf("CreateSession: prepare failed user=%s err=%v", userID, err)
-		return StudySession{}, err
-	}
+	s.logger.DebugContext(ctx, "creating study session", "userId", userID, "subject", session.Subject)
 
-	created, err := s.sessions.Create(session)
+	var created StudySession
+	err := s.atomically(ctx, func(scoped *Service) error {
+		if err := scoped.prepareSession(ctx, &session, true); err != nil {
+			s.logger.DebugContext(ctx, "study session rejected", "userId", userID, "error", err)
+			return err
+		}
+		overlaps := session.OverlapsWith
+		session.OverlapsWith = nil
+
+		var err error
+		created, err = scoped.sessions.Create(session)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "persist study session failed", "userId", userID, "error", err)
+			return err
+		}
+		if err := scoped.recordRevision(userID, action, nil, &created); err != nil {
+			return err
+		}
+		created.OverlapsWith = overlaps
+		return nil
+	})
 	if err != nil {
-		log.Printf("CreateSession: persist failed user=%s err=%v", userID, err)
 		return StudySession{}, err
 	}
-	log.Printf("CreateSession: success user=%s session=%s subjectID=%s", userID, created.ID, created.SubjectID)
+	s.logger.InfoContext(ctx, "study session created", "userId", userID, "sessionId", created.ID, "subjectId", created.SubjectID)
+	s.recorder.SessionLogged(created.DurationMinutes)
 	return created, nil
 }
 
-// UpdateSession persists changes to an existing study session.
-func (s *Service) UpdateSession(userID string, session StudySession) (StudySession, error) {
+// UpdateSession persists changes to an existing study session. When
+// session.Version is non-zero the update only applies to that version.
+func (s *Service) UpdateSession(ctx context.Context, userID string, session StudySession) (StudySession, error) {
+	return s.updateSession(ctx, userID, session, RevisionUpdate)
+}
+
+func (s *Service) updateSession(ctx context.Context, userID string, session StudySession, action string) (StudySession, error) {
 	session.UserID = userID
-	if err := s.prepareSession(&session, false); err != nil {
+
+	var updated StudySession
+	err := s.atomically(ctx, func(scoped *Service) error {
+		existing, err := scoped.sessions.Get(userID, session.ID)
+		if err != nil {
+			return err
+		}
+		// Tags and mode left out of the request are kept. Segments are kept
+		// only when the times are left out too; new times without segments
+		// turn the session back into a single manual block.
+		if session.Tags == nil {
+			session.Tags = existing.Tags
+		}
+		if session.Segments == nil {
+			if session.StartTime.IsZero() && session.EndTime.IsZero() {
+				session.Segments = existing.Segments
+			} else {
+				session.Segments = []Segment{}
+				if session.Mode == "" {
+					session.Mode = ModeManual
+				}
+			}
+		}
+		if session.Mode == "" {
+			session.Mode = existing.Mode
+		}
+		session.SubjectID = existing.SubjectID
+		if err := scoped.prepareSession(ctx, &session, false); err != nil {
+			return err
+		}
+		session.CreatedAt = existing.CreatedAt
+		overlaps := session.OverlapsWith
+		session.OverlapsWith = nil
+
+		updated, err = scoped.sessions.Update(session)
+		if err != nil {
+			return err
+		}
+		if err := scoped.recordRevision(userID, action, &existing, &updated); err != nil {
+			return err
+		}
+		updated.OverlapsWith = overlaps
+		return nil
+	})
+	if err != nil {
+		return StudySession{}, err
+	}
+	return updated, nil
+}
+
+// PatchSession applies a JSON Merge Patch to a stored session. The merged
+// result is validated and its duration recomputed exactly like a full update.
+// The read, merge and write share one transaction, and without a version the
+// write still only applies to the version the patch was merged into, so a
+// concurrent edit is reported as a conflict instead of being overwritten.
+func (s *Service) PatchSession(ctx context.Context, userID, id string, patch []byte, version int) (StudySession, error) {
+	var updated StudySession
+	err := s.atomically(ctx, func(scoped *Service) error {
+		existing, err := scoped.sessions.Get(userID, id)
+		if err != nil {
+			return err
+		}
+
+		var merged StudySession
+		if err := applyPatch(existing, patch, &merged); err != nil {
+			return err
+		}
+		// Server-managed fields cannot be patched.
+		merged.ID = existing.ID
+		merged.SubjectID = existing.SubjectID
+		merged.CreatedAt = existing.CreatedAt
+		merged.Version = version
+		if merged.Version == 0 {
+			merged.Version = existing.Version
+		}
+		// A null tags member clears the tags rather than keeping them.
+		if merged.Tags == nil {
+			merged.Tags = []string{}
+		}
+		if merged.Segments == nil {
+			merged.Segments = []Segment{}
+		}
+		// As with PUT, new times without segments turn a segmented session
+		// back into a single manual block instead of being overridden by the
+		// stored segments.
+		members := patchMembers(patch)
+		if (members["startTime"] || members["endTime"]) && !members["segments"] {
+			merged.Segments = []Segment{}
+			if !members["mode"] {
+				merged.Mode = ModeManual
+			}
+		}
+
+		updated, err = scoped.UpdateSession(ctx, userID, merged)
+		return err
+	})
+	if err != nil {
 		return StudySession{}, err
 	}
+	return updated, nil
+}
 
-	return s.sessions.Update(session)
+// DeleteSession removes a study session. A non-zero version must match the stored one.
+func (s *Service) DeleteSession(ctx context.Context, userID, id string, version int) error {
+	return s.atomically(ctx, func(scoped *Service) error {
+		existing, err := scoped.sessions.Get(userID, id)
+		if err != nil {
+			return err
+		}
+		if err := scoped.sessions.Delete(userID, id, version); err != nil {
+			return err
+		}
+		return scoped.recordRevision(userID, RevisionDelete, &existing, nil)
+	})
 }
 
-// DeleteSession removes a study session.
-func (s *Service) DeleteSession(userID, id string) error {
-	return s.sessions.Delete(userID, id)
+// ListSessions returns the user's sessions matching filter, newest first.
+func (s *Service) ListSessions(ctx context.Context, userID string, filter SessionFilter) ([]StudySession, error) {
+	if len(filter.Tags) > 0 {
+		tags, err := normalizeTags(filter.Tags)
+		if err != nil {
+			return nil, err
+		}
+		filter.Tags = tags
+	}
+	return s.sessions.List(userID, filter)
 }
 
-// ListSessions returns all stored sessions; extend with filters later.
-func (s *Service) ListSessions(userID string) ([]StudySession, error) {
-	return s.sessions.List(userID)
+// SummaryOptions tunes BuildSummary. A SubjectDepth of 1 groups BySubject
+// under top-level subjects, 2 under their children and so on; zero keeps
+// each session's own subject.
+type SummaryOptions struct {
+	SubjectDepth int
 }
 
 // BuildSummary aggregates study data for dashboards.
-func (s *Service) BuildSummary(userID string) (ProgressSummary, error) {
-	sessions, err := s.sessions.List(userID)
+func (s *Service) BuildSummary(ctx context.Context, userID string, opts SummaryOptions) (ProgressSummary, error) {
+	sessions, err := s.sessions.List(userID, SessionFilter{})
+	if err != nil {
+		return ProgressSummary{}, err
+	}
+	subjectKey, err := s.subjectKeyer(ctx, userID, opts.SubjectDepth)
 	if err != nil {
 		return ProgressSummary{}, err
 	}
 
 	summary := ProgressSummary{
-		BySubject: make(map[string]int),
+		BySubject:    make(map[string]int),
+		SubjectNames: make(map[string]string),
+		ByTag:        make(map[string]int),
 	}
 
 	if len(sessions) == 0 {
@@ -100,7 +300,12 @@ func (s *Service) BuildSummary(userID string) (ProgressSummary, error) {
 	for _, session := range sessions {
 		summary.TotalMinutes += session.DurationMinutes
 		summary.SessionCount++
-		summary.BySubject[session.Subject] += session.DurationMinutes
+		subject, name := subjectKey(session)
+		summary.BySubject[subject] += session.DurationMinutes
+		summary.SubjectNames[subject] = name
+		for _, tag := range session.Tags {
+			summary.ByTag[tag] += session.DurationMinutes
+		}
 
 		start := session.StartTime.In(loc)
 		day := startOfDay(start)
@@ -114,7 +319,7 @@ func (s *Service) BuildSummary(userID string) (ProgressSummary, error) {
 		stat.TotalMinutes += session.DurationMinutes
 		stat.SessionCount++
 		stat.AverageMinutes = float64(stat.TotalMinutes) / float64(stat.SessionCount)
-		stat.BySubject[session.Subject] += session.DurationMinutes
+		stat.BySubject[subject] += session.DurationMinutes
 
 		if day.Equal(startToday) {
 			summary.TodayMinutes += session.DurationMinutes
@@ -137,18 +342,83 @@ func (s *Service) BuildSummary(userID string) (ProgressSummary, error) {
 	return summary, nil
 }
 
+// subjectKeyer returns how BuildSummary groups a session: by the ID and
+// current name of its subject, or of that subject's ancestor at depth.
+// Sessions whose subject was deleted keep the name they were logged with.
+func (s *Service) subjectKeyer(ctx context.Context, userID string, depth int) (func(StudySession) (id, name string), error) {
+	subjects, err := s.ListSubjects(ctx, userID, SubjectFilter{IncludeArchived: true})
+	if err != nil {
+		return nil, err
+	}
+	parents := make(map[string]string, len(subjects))
+	names := make(map[string]string, len(subjects))
+	for _, subject := range subjects {
+		parents[subject.ID] = subject.ParentID
+		names[subject.ID] = subject.Name
+	}
+	return func(session StudySession) (string, string) {
+		id := session.SubjectID
+		if id == "" {
+			return session.Subject, session.Subject
+		}
+		if depth > 0 {
+			id = ancestorAt(parents, id, depth)
+		}
+		if name, ok := names[id]; ok {
+			return id, name
+		}
+		return id, session.Subject
+	}, nil
+}
+
 // Subject operations ----------------------------------------------------------
 
-// ListSubjects returns subjects in alphabetical order.
-func (s *Service) ListSubjects(userID string) ([]Subject, error) {
+// ListSubjects returns subjects in alphabetical order. Rollup totals count
+// archived descendants even when the filter leaves them out.
+func (s *Service) ListSubjects(ctx context.Context, userID string, filter SubjectFilter) ([]Subject, error) {
 	if s.subjects == nil {
 		return nil, nil
 	}
-	return s.subjects.List(userID)
+	subjects, err := s.subjects.List(userID)
+	if err != nil || filter.IncludeArchived {
+		return subjects, err
+	}
+	live := subjects[:0]
+	for _, subject := range subjects {
+		if !subject.Archived {
+			live = append(live, subject)
+		}
+	}
+	return live, nil
+}
+
+// ArchiveSubject retires a subject. Its sessions stay in history and
+// analytics, but new sessions cannot be logged against it until it is
+// unarchived.
+func (s *Service) ArchiveSubject(ctx context.Context, userID, id string, version int) (Subject, error) {
+	return s.setArchived(ctx, userID, id, true, version)
+}
+
+// UnarchiveSubject makes an archived subject available for new sessions.
+func (s *Service) UnarchiveSubject(ctx context.Context, userID, id string, version int) (Subject, error) {
+	return s.setArchived(ctx, userID, id, false, version)
+}
+
+func (s *Service) setArchived(ctx context.Context, userID, id string, archived bool, version int) (Subject, error) {
+	if s.subjects == nil {
+		return Subject{}, errors.New("subject repository not configured")
+	}
+	subject, err := s.subjects.SetArchived(userID, id, archived, version)
+	if err != nil {
+		return Subject{}, err
+	}
+	s.logger.InfoContext(ctx, "subject archive state changed", "userId", userID, "subjectId", id, "archived", archived)
+	return subject, nil
 }
 
-// CreateSubject adds a new subject to the catalogue.
-func (s *Service) CreateSubject(userID string, subject Subject) (Subject, error) {
+// CreateSubject adds a new subject to the catalogue. A name that is already
+// taken is rejected rather than updating the subject that holds it.
+func (s *Service) CreateSubject(ctx context.Context, userID string, subject Subject) (Subject, error) {
 	if s.subjects == nil {
 		return Subject{}, errors.New("subject repository not configured")
 	}
@@ -156,6 +426,7 @@ func (s *Service) CreateSubject(userID string, subject Subject) (Subject, error)
 	subject.UserID = userID
 	subject.Name = strings.TrimSpace(subject.Name)
 	subject.Color = strings.TrimSpace(subject.Color)
+	subject.ParentID = strings.TrimSpace(subject.ParentID)
 	if subject.Name == "" {
 		return Subject{}, ErrSubjectNameEmpty
 	}
@@ -164,14 +435,19 @@ func (s *Service) CreateSubject(userID string, subject Subject) (Subject, error)
 	if subject.ID == "" {
 		subject.ID = generateID()
 	}
+	if err := s.checkParent(userID, subject.ID, subject.ParentID); err != nil {
+		return Subject{}, err
+	}
 	subject.CreatedAt = now
 	subject.UpdatedAt = now
 
 	return s.subjects.Create(subject)
 }
 
-// UpdateSubject allows renaming or recolouring a subject.
-func (s *Service) UpdateSubject(userID string, subject Subject) (Subject, error) {
+// UpdateSubject allows renaming or recolouring a subject; MoveSubject
+// changes its parent. When subject.Version is non-zero the update only
+// applies to that version.
+func (s *Service) UpdateSubject(ctx context.Context, userID string, subject Subject) (Subject, error) {
 	if s.subjects == nil {
 		return Subject{}, errors.New("subject repository not configured")
 	}
@@ -183,49 +459,141 @@ func (s *Service) UpdateSubject(userID string, subject Subject) (Subject, error)
 		return Subject{}, ErrSubjectNameEmpty
 	}
 
-	existing, err := s.subjects.Get(userID, subject.ID)
+	var updated Subject
+	err := s.atomically(ctx, func(scoped *Service) error {
+		existing, err := scoped.subjects.Get(userID, subject.ID)
+		if err != nil {
+			return err
+		}
+
+		subject.CreatedAt = existing.CreatedAt
+		subject.UpdatedAt = time.Now().UTC()
+		subject.ParentID = existing.ParentID
+
+		updated, err = scoped.subjects.Update(subject)
+		if err != nil || updated.Name == existing.Name {
+			return err
+		}
+		// Sessions store their subject's name too; keep them in step.
+		return scoped.resubjectSessions(userID, subject.ID, updated)
+	})
 	if err != nil {
 		return Subject{}, err
 	}
+	return updated, nil
+}
+
+// resubjectSessions moves the sessions of fromID to subject to, or renames
+// them when to is the same subject, and records an update revision for each
+// live session. The sessions change in one statement, so their new state is
+// derived here rather than read back one by one.
+func (s *Service) resubjectSessions(userID, fromID string, to Subject) error {
+	before, err := s.sessions.List(userID, SessionFilter{SubjectID: fromID})
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if err := s.subjects.ReassignSessions(userID, fromID, to, now); err != nil {
+		return err
+	}
+
+	revisions := make([]SessionRevision, len(before))
+	for i := range before {
+		after := before[i]
+		after.SubjectID = to.ID
+		after.Subject = to.Name
+		after.LastUpdated = now
+		after.Version++
+		revisions[i] = newRevision(userID, RevisionUpdate, &before[i], &after)
+	}
+	return s.appendRevisions(revisions...)
+}
+
+// PatchSubject applies a JSON Merge Patch to a stored subject. Like
+// PatchSession, it merges and writes in one transaction against the version
+// it read when no version is given.
+func (s *Service) PatchSubject(ctx context.Context, userID, id string, patch []byte, version int) (Subject, error) {
+	if s.subjects == nil {
+		return Subject{}, errors.New("subject repository not configured")
+	}
 
-	subject.CreatedAt = existing.CreatedAt
-	subject.UpdatedAt = time.Now().UTC()
+	var updated Subject
+	err := s.atomically(ctx, func(scoped *Service) error {
+		existing, err := scoped.subjects.Get(userID, id)
+		if err != nil {
+			return err
+		}
+
+		var merged Subject
+		if err := applyPatch(existing, patch, &merged); err != nil {
+			return err
+		}
+		merged.ID = existing.ID
+		merged.Version = version
+		if merged.Version == 0 {
+			merged.Version = existing.Version
+		}
 
-	return s.subjects.Update(subject)
+		updated, err = scoped.UpdateSubject(ctx, userID, merged)
+		return err
+	})
+	if err != nil {
+		return Subject{}, err
+	}
+	return updated, nil
 }
 
-// DeleteSubject removes a subject from the catalogue.
-func (s *Service) DeleteSubject(userID, id string) error {
+// DeleteSubject removes a subject from the catalogue. A non-zero version
+// must match the stored one. Subjects with children must be emptied first.
+func (s *Service) DeleteSubject(ctx context.Context, userID, id string, version int) error {
 	if s.subjects == nil {
 		return errors.New("subject repository not configured")
 	}
-	return s.subjects.Delete(userID, id)
+	return s.atomically(ctx, func(scoped *Service) error {
+		subjects, err := scoped.subjects.List(userID)
+		if err != nil {
+			return err
+		}
+		for _, subject := range subjects {
+			if subject.ParentID == id {
+				return ErrSubjectHasChildren
+			}
+		}
+		return scoped.subjects.Delete(userID, id, version)
+	})
 }
 
 // prepareSession validates and normalises session data prior to persistence.
-func (s *Service) prepareSession(session *StudySession, isCreate bool) error {
+// session.SubjectID is the subject the session had before this write, if
+// any; only sessions already on an archived or trashed subject may stay on
+// it.
+func (s *Service) prepareSession(ctx context.Context, session *StudySession, isCreate bool) error {
+	previousSubjectID := session.SubjectID
 	session.Subject = strings.TrimSpace(session.Subject)
 	if session.Subject == "" {
 		return ErrMissingSubject
 	}
 
 	if s.subjects != nil && session.UserID != "" {
-		log.Printf("prepareSession: lookup subject user=%s subject=%s", session.UserID, session.Subject)
 		subject, err := s.subjects.GetByName(session.UserID, session.Subject)
 		if err != nil {
 			if errors.Is(err, ErrSubjectNotFound) {
-				log.Printf("prepareSession: subject missing, creating user=%s subject=%s", session.UserID, session.Subject)
-				subject, err = s.createSubjectOnDemand(session.UserID, session.Subject, session.SubjectColor)
+				subject, err = s.trashedSubject(session.UserID, previousSubjectID, session.Subject)
+			}
+			if errors.Is(err, ErrSubjectNotFound) {
+				subject, err = s.createSubjectOnDemand(ctx, session.UserID, session.Subject, session.SubjectColor)
 				if err != nil {
-					log.Printf("prepareSession: subject create failed user=%s subject=%s err=%v", session.UserID, session.Subject, err)
 					return err
 				}
-			} else {
-				log.Printf("prepareSession: lookup failed user=%s subject=%s err=%v", session.UserID, session.Subject, err)
+			} else if err != nil {
+				s.logger.ErrorContext(ctx, "subject lookup failed", "userId", session.UserID, "subject", session.Subject, "error", err)
 				return err
 			}
 		}
-		log.Printf("prepareSession: using subjectID=%s color=%s", subject.ID, subject.Color)
+		if subject.Archived && subject.ID != previousSubjectID {
+			return ErrSubjectArchived
+		}
+		s.logger.DebugContext(ctx, "resolved session subject", "subjectId", subject.ID, "color", subject.Color)
 		session.SubjectID = subject.ID
 		session.Subject = subject.Name
 		if session.SubjectColor == "" && subject.Color != "" {
@@ -233,19 +601,69 @@ func (s *Service) prepareSession(session *StudySession, isCreate bool) error {
 		}
 	}
 
-	if session.StartTime.IsZero() || session.EndTime.IsZero() {
-		return ErrInvalidTiming
+	tags, err := normalizeTags(session.Tags)
+	if err != nil {
+		return err
+	}
+	session.Tags = tags
+
+	ratings := []struct {
+		field string
+		value *int
+	}{
+		{"focus", session.Focus},
+		{"mood", session.Mood},
+		{"energy", session.Energy},
+		{"difficulty", session.Difficulty},
+	}
+	for _, rating := range ratings {
+		if rating.value != nil && (*rating.value < minRating || *rating.value > maxRating) {
+			return &RatingError{Field: rating.field}
+		}
 	}
 
-	session.StartTime = session.StartTime.UTC()
-	session.EndTime = session.EndTime.UTC()
-
-	if !session.EndTime.After(session.StartTime) {
-		return ErrInvalidTiming
+	var pomodoro *PomodoroSettings
+	switch session.Mode {
+	case "", ModeManual:
+		session.Mode = ModeManual
+	case ModePomodoro:
+		if len(session.Segments) == 0 {
+			return ErrSegmentsRequired
+		}
+		settings, err := s.PomodoroSettings(ctx, session.UserID)
+		if err != nil {
+			return err
+		}
+		pomodoro = &settings
+	default:
+		return ErrInvalidMode
 	}
 
-	duration := session.EndTime.Sub(session.StartTime).Minutes()
-	session.DurationMinutes = int(math.Ceil(duration))
+	if len(session.Segments) > 0 {
+		// Segmented sessions span their segments and only count focused time.
+		focused, err := prepareSegments(session.Segments, pomodoro)
+		if err != nil {
+			return err
+		}
+		session.StartTime = session.Segments[0].StartTime
+		session.EndTime = session.Segments[len(session.Segments)-1].EndTime
+		session.DurationMinutes = focused
+	} else {
+		session.Segments = nil
+		if session.StartTime.IsZero() {
+			return &TimingError{Field: "startTime", Reason: "is required"}
+		}
+		if session.EndTime.IsZero() {
+			return &TimingError{Field: "endTime", Reason: "is required"}
+		}
+		session.StartTime = session.StartTime.UTC()
+		session.EndTime = session.EndTime.UTC()
+		if !session.EndTime.After(session.StartTime) {
+			return &TimingError{Field: "endTime", Reason: "must be after startTime"}
+		}
+		duration := session.EndTime.Sub(session.StartTime).Minutes()
+		session.DurationMinutes = int(math.Ceil(duration))
+	}
 
 	now := time.Now().UTC()
 	session.LastUpdated = now
@@ -254,11 +672,47 @@ func (s *Service) prepareSession(session *StudySession, isCreate bool) error {
 			session.ID = generateID()
 		}
 		session.CreatedAt = now
+		session.Version = 1
 	}
 
+	overlaps, err := s.checkOverlaps(ctx, *session)
+	if err != nil {
+		return err
+	}
+	session.OverlapsWith = overlaps
+	return nil
+}
+
+// applyPatch merges a JSON Merge Patch into current and decodes the result into out.
+func applyPatch(current any, patch []byte, out any) error {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	merged, err := mergepatch.Apply(doc, patch)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(merged, out); err != nil {
+		return mergepatch.ErrInvalidPatch
+	}
 	return nil
 }
 
+// patchMembers reports which top-level members a merge patch sets. Patches
+// that are not JSON objects set none; applyPatch reports those.
+func patchMembers(patch []byte) map[string]bool {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil {
+		return nil
+	}
+	set := make(map[string]bool, len(members))
+	for name := range members {
+		set[name] = true
+	}
+	return set
+}
+
 func buildEmptyTrend() []DailyStat {
 	now := time.Now().In(time.Local)
 	return buildDailyTrend(startOfDay(now), map[string]*DailyStat{})
@@ -333,13 +787,31 @@ func generateID() string {
 	return hex.EncodeToString(b[:])
 }
 
+// trashedSubject returns the trashed subject id when it is still called
+// name. Sessions keep their subject while it is in the trash, so they can be
+// edited without restoring it first.
+func (s *Service) trashedSubject(userID, id, name string) (Subject, error) {
+	if id == "" {
+		return Subject{}, ErrSubjectNotFound
+	}
+	trashed, err := s.subjects.ListDeleted(userID)
+	if err != nil {
+		return Subject{}, err
+	}
+	for _, subject := range trashed {
+		if subject.ID == id && strings.EqualFold(subject.Name, name) {
+			return subject, nil
+		}
+	}
+	return Subject{}, ErrSubjectNotFound
+}
+
 const defaultSubjectColor = "#6366f1"
 
-func (s *Service) createSubjectOnDemand(userID, name, color string) (Subject, error) {
+func (s *Service) createSubjectOnDemand(ctx context.Context, userID, name, color string) (Subject, error) {
 	if s.subjects == nil {
 		return Subject{}, ErrUnknownSubject
 	}
-	log.Printf("createSubjectOnDemand: user=%s subject=%s", userID, name)
 	now := time.Now().UTC()
 	subject := Subject{
 		ID:        generateID(),
@@ -355,12 +827,19 @@ func (s *Service) createSubjectOnDemand(userID, name, color string) (Subject, er
 	created, err := s.subjects.Create(subject)
 	if err != nil {
 		if errors.Is(err, ErrSubjectNameExists) {
-			log.Printf("createSubjectOnDemand: already exists user=%s subject=%s", userID, name)
-			return s.subjects.GetByName(userID, name)
+			// Another request created it first.
+			existing, getErr := s.subjects.GetByName(userID, name)
+			if errors.Is(getErr, ErrSubjectNotFound) {
+				return Subject{}, err
+			}
+			return existing, getErr
+		}
+		if errors.Is(err, ErrSubjectInTrash) {
+			return Subject{}, err
 		}
-		log.Printf("createSubjectOnDemand: failed user=%s subject=%s err=%v", userID, name, err)
+		s.logger.ErrorContext(ctx, "create subject on demand failed", "userId", userID, "subject", name, "error", err)
 		return Subject{}, err
 	}
-	log.Printf("createSubjectOnDemand: created subjectID=%s user=%s", created.ID, userID)
+	s.logger.InfoContext(ctx, "subject created on demand", "userId", userID, "subjectId", created.ID)
 	return created, nil
 }
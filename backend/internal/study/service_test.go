@@ -0,0 +1,295 @@
+package study
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"studytracker/internal/platform/database"
+	"studytracker/internal/platform/mergepatch"
+)
+
+// Users created by newTestService; some tables reference users by foreign key.
+const (
+	testUser  = "user-1"
+	otherUser = "user-2"
+)
+
+// newTestService returns a Service backed by a fresh in-memory SQLite
+// database with every migration applied.
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	db, err := database.Open(database.Config{DSN: "file::memory:?_pragma=foreign_keys(ON)"})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// Every connection to :memory: is a separate database, so keep one.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	if err := database.ApplyMigrations(context.Background(), db); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+	for _, id := range []string{testUser, otherUser} {
+		if _, err := db.Exec(`INSERT INTO users (id, email, provider, created_at, updated_at) VALUES (?, ?, 'local', ?, ?)`,
+			id, id+"@example.com", time.Now(), time.Now()); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewService(Repositories{
+		Sessions:    NewSQLSessionRepository(db),
+		Subjects:    NewSQLSubjectRepository(db, logger),
+		Revisions:   NewSQLRevisionRepository(db),
+		Tags:        NewSQLTagRepository(db),
+		Pomodoro:    NewSQLPomodoroSettingsRepository(db),
+		Preferences: NewSQLPreferencesRepository(db),
+	}, NewSQLTransactor(db, logger), Config{}, nil, logger)
+}
+
+// at returns 2026-10-01 at the given hour and minute in UTC.
+func at(hour, minute int) time.Time {
+	return time.Date(2026, time.October, 1, hour, minute, 0, 0, time.UTC)
+}
+
+// mustCreateSession logs a session of subject from start to end.
+func mustCreateSession(t *testing.T, svc *Service, subject string, start, end time.Time) StudySession {
+	t.Helper()
+	session, err := svc.CreateSession(context.Background(), testUser, StudySession{
+		Subject:   subject,
+		StartTime: start,
+		EndTime:   end,
+	})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	return session
+}
+
+func TestCreateSessionTiming(t *testing.T) {
+	tests := []struct {
+		name      string
+		start     time.Time
+		end       time.Time
+		wantField string
+		wantMins  int
+	}{
+		{name: "valid", start: at(10, 0), end: at(11, 30), wantMins: 90},
+		{name: "partial minute rounds up", start: at(10, 0), end: at(10, 0).Add(61 * time.Second), wantMins: 2},
+		{name: "missing start", end: at(11, 0), wantField: "startTime"},
+		{name: "missing end", start: at(10, 0), wantField: "endTime"},
+		{name: "end before start", start: at(11, 0), end: at(10, 0), wantField: "endTime"},
+		{name: "zero length", start: at(10, 0), end: at(10, 0), wantField: "endTime"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			session, err := svc.CreateSession(context.Background(), testUser, StudySession{
+				Subject:   "Physics",
+				StartTime: tt.start,
+				EndTime:   tt.end,
+			})
+			if tt.wantField != "" {
+				var timing *TimingError
+				if !errors.As(err, &timing) || timing.Field != tt.wantField {
+					t.Fatalf("err = %v, want a timing error on %s", err, tt.wantField)
+				}
+				if !errors.Is(err, ErrInvalidTiming) {
+					t.Fatalf("err = %v, want it to match ErrInvalidTiming", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			if session.DurationMinutes != tt.wantMins {
+				t.Fatalf("duration = %d, want %d", session.DurationMinutes, tt.wantMins)
+			}
+		})
+	}
+}
+
+func TestUpdateSessionHonoursVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version func(current int) int
+		wantErr error
+	}{
+		{name: "unconditional", version: func(int) int { return 0 }},
+		{name: "current version", version: func(current int) int { return current }},
+		{name: "stale version", version: func(current int) int { return current - 1 }, wantErr: ErrVersionConflict},
+		{name: "unknown tag", version: func(int) int { return -1 }, wantErr: ErrVersionConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			ctx := context.Background()
+			session := mustCreateSession(t, svc, "Physics", at(10, 0), at(11, 0))
+			session.Notes = "first"
+			session, err := svc.UpdateSession(ctx, testUser, session)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			session.Notes = "second"
+			session.Version = tt.version(session.Version)
+			updated, err := svc.UpdateSession(ctx, testUser, session)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && updated.Version != 3 {
+				t.Fatalf("version = %d, want 3", updated.Version)
+			}
+		})
+	}
+}
+
+func TestPatchSession(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		version func(current int) int
+		check   func(t *testing.T, before, after StudySession)
+		wantErr error
+	}{
+		{
+			name:  "keeps fields left out",
+			patch: `{"notes":"chapter 3"}`,
+			check: func(t *testing.T, before, after StudySession) {
+				if after.Notes != "chapter 3" || after.Subject != before.Subject || !after.StartTime.Equal(before.StartTime) {
+					t.Fatalf("patched session = %+v", after)
+				}
+				if !reflect.DeepEqual(after.Tags, before.Tags) {
+					t.Fatalf("tags = %v, want %v", after.Tags, before.Tags)
+				}
+			},
+		},
+		{
+			name:  "recomputes duration",
+			patch: `{"endTime":"2026-10-01T12:00:00Z"}`,
+			check: func(t *testing.T, _, after StudySession) {
+				if after.DurationMinutes != 120 {
+					t.Fatalf("duration = %d, want 120", after.DurationMinutes)
+				}
+			},
+		},
+		{
+			name:  "null clears tags",
+			patch: `{"tags":null}`,
+			check: func(t *testing.T, _, after StudySession) {
+				if len(after.Tags) != 0 {
+					t.Fatalf("tags = %v, want none", after.Tags)
+				}
+			},
+		},
+		{
+			name:  "ignores server-managed fields",
+			patch: `{"id":"other","version":99,"createdAt":"2000-01-01T00:00:00Z"}`,
+			check: func(t *testing.T, before, after StudySession) {
+				if after.ID != before.ID || !after.CreatedAt.Equal(before.CreatedAt) || after.Version != before.Version+1 {
+					t.Fatalf("patched session = %+v", after)
+				}
+			},
+		},
+		{
+			name:    "validates the merged session",
+			patch:   `{"endTime":"2026-10-01T09:00:00Z"}`,
+			wantErr: ErrInvalidTiming,
+		},
+		{
+			name:    "stale If-Match",
+			patch:   `{"notes":"late"}`,
+			version: func(current int) int { return current + 1 },
+			wantErr: ErrVersionConflict,
+		},
+		{
+			name:    "invalid document",
+			patch:   `{"notes":`,
+			wantErr: mergepatch.ErrInvalidPatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			ctx := context.Background()
+			before, err := svc.CreateSession(ctx, testUser, StudySession{
+				Subject: "Physics", StartTime: at(10, 0), EndTime: at(11, 0), Tags: []string{"exam"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			version := 0
+			if tt.version != nil {
+				version = tt.version(before.Version)
+			}
+
+			after, err := svc.PatchSession(ctx, testUser, before.ID, []byte(tt.patch), version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, before, after)
+			}
+		})
+	}
+}
+
+func TestPatchSubject(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	subject, err := svc.CreateSubject(ctx, testUser, Subject{Name: "Physics", Color: "#ff0000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patched, err := svc.PatchSubject(ctx, testUser, subject.ID, []byte(`{"color":"#00ff00"}`), subject.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Name != "Physics" || patched.Color != "#00ff00" || patched.Version != subject.Version+1 {
+		t.Fatalf("patched subject = %+v", patched)
+	}
+
+	// The first patch moved the subject on, so the old version conflicts.
+	if _, err := svc.PatchSubject(ctx, testUser, subject.ID, []byte(`{"name":"Physik"}`), subject.Version); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("err = %v, want ErrVersionConflict", err)
+	}
+}
+
+func TestCreateSubjectNameConflict(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    string
+		trashed bool
+		want    error
+	}{
+		{name: "own live subject", user: testUser, want: ErrSubjectNameExists},
+		{name: "own trashed subject", user: testUser, trashed: true, want: ErrSubjectInTrash},
+		{name: "another user's live subject", user: otherUser, want: ErrSubjectNameExists},
+		// Only the owner may learn that the subject is in the trash.
+		{name: "another user's trashed subject", user: otherUser, trashed: true, want: ErrSubjectNameExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			ctx := context.Background()
+			existing, err := svc.CreateSubject(ctx, testUser, Subject{Name: "Physics"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.trashed {
+				if err := svc.DeleteSubject(ctx, testUser, existing.ID, 0); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if _, err := svc.CreateSubject(ctx, tt.user, Subject{Name: "physics"}); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package study
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"strings"
 	"time"
 
@@ -11,7 +12,7 @@ import (
 
 // SQLSessionRepository persists study sessions to SQLite.
 type SQLSessionRepository struct {
-	db        *sql.DB
+	db        database.Executor
 	useDollar bool
 }
 
@@ -27,8 +28,9 @@ func (r *SQLSessionRepository) Create(session StudySession) (StudySession, error
 	const query = `
 		INSERT INTO study_sessions (
 			id, user_id, subject_id, subject_name, notes, reflection,
-			start_time, end_time, duration_minutes, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+			start_time, end_time, duration_minutes, created_at, updated_at, version,
+			focus, mood, energy, difficulty, mode
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 	`
 
 	_, err := r.db.ExecContext(
@@ -45,10 +47,19 @@ func (r *SQLSessionRepository) Create(session StudySession) (StudySession, error
 		session.DurationMinutes,
 		session.CreatedAt.UTC(),
 		session.LastUpdated.UTC(),
+		session.Version,
+		nullIfNil(session.Focus),
+		nullIfNil(session.Mood),
+		nullIfNil(session.Energy),
+		nullIfNil(session.Difficulty),
+		modeOrDefault(session.Mode),
 	)
 	if err != nil {
 		return StudySession{}, err
 	}
+	if err := r.saveDetails(session); err != nil {
+		return StudySession{}, err
+	}
 
 	return session, nil
 }
@@ -57,11 +68,14 @@ func (r *SQLSessionRepository) Update(session StudySession) (StudySession, error
 	const query = `
 		UPDATE study_sessions
 		SET subject_id = ?, subject_name = ?, notes = ?, reflection = ?,
-			start_time = ?, end_time = ?, duration_minutes = ?, updated_at = ?
-		WHERE id = ? AND user_id = ?;
+			start_time = ?, end_time = ?, duration_minutes = ?, updated_at = ?,
+			focus = ?, mood = ?, energy = ?, difficulty = ?, mode = ?,
+			version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)
+		RETURNING version;
 	`
 
-	res, err := r.db.ExecContext(
+	err := r.db.QueryRowContext(
 		context.Background(),
 		r.rebind(query),
 		session.SubjectID,
@@ -72,28 +86,123 @@ func (r *SQLSessionRepository) Update(session StudySession) (StudySession, error
 		session.EndTime.UTC(),
 		session.DurationMinutes,
 		session.LastUpdated.UTC(),
+		nullIfNil(session.Focus),
+		nullIfNil(session.Mood),
+		nullIfNil(session.Energy),
+		nullIfNil(session.Difficulty),
+		modeOrDefault(session.Mode),
 		session.ID,
 		session.UserID,
-	)
+		session.Version,
+		session.Version,
+	).Scan(&session.Version)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return StudySession{}, r.missingOrConflict(session.UserID, session.ID)
+		}
+		return StudySession{}, err
+	}
+	if err := r.saveDetails(session); err != nil {
 		return StudySession{}, err
 	}
 
+	return session, nil
+}
+
+// saveDetails replaces the tags and segments stored alongside a session.
+func (r *SQLSessionRepository) saveDetails(session StudySession) error {
+	if err := r.replaceTags(session.UserID, session.ID, session.Tags); err != nil {
+		return err
+	}
+	return r.replaceSegments(session.ID, session.Segments)
+}
+
+func (r *SQLSessionRepository) replaceSegments(sessionID string, segments []Segment) error {
+	const (
+		clear  = `DELETE FROM session_segments WHERE session_id = ?;`
+		insert = `
+			INSERT INTO session_segments (session_id, position, kind, start_time, end_time, planned_minutes, interrupted)
+			VALUES (?, ?, ?, ?, ?, ?, ?);
+		`
+	)
+
+	ctx := context.Background()
+	if _, err := r.db.ExecContext(ctx, r.rebind(clear), sessionID); err != nil {
+		return err
+	}
+	for i, seg := range segments {
+		_, err := r.db.ExecContext(ctx, r.rebind(insert),
+			sessionID, i, seg.Kind, seg.StartTime.UTC(), seg.EndTime.UTC(), seg.PlannedMinutes, seg.Interrupted)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceTags sets a session's tags, creating any the user does not have yet.
+func (r *SQLSessionRepository) replaceTags(userID, sessionID string, tags []string) error {
+	const (
+		unlink    = `DELETE FROM session_tags WHERE session_id = ?;`
+		createTag = `
+			INSERT INTO tags (id, user_id, name, created_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (user_id, name) DO NOTHING;
+		`
+		link = `
+			INSERT INTO session_tags (session_id, tag_id)
+			SELECT ?, id FROM tags WHERE user_id = ? AND name = ?;
+		`
+	)
+
+	ctx := context.Background()
+	if _, err := r.db.ExecContext(ctx, r.rebind(unlink), sessionID); err != nil {
+		return err
+	}
+	for _, name := range tags {
+		if _, err := r.db.ExecContext(ctx, r.rebind(createTag), generateID(), userID, name, time.Now().UTC()); err != nil {
+			return err
+		}
+		if _, err := r.db.ExecContext(ctx, r.rebind(link), sessionID, userID, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete marks the session deleted; the row stays behind as a tombstone for sync.
+func (r *SQLSessionRepository) Delete(userID, id string, version int) error {
+	const query = `
+		UPDATE study_sessions
+		SET deleted_at = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?);
+	`
+
+	now := time.Now().UTC()
+	res, err := r.db.ExecContext(context.Background(), r.rebind(query), now, now, id, userID, version, version)
+	if err != nil {
+		return err
+	}
+
 	rows, err := res.RowsAffected()
 	if err != nil {
-		return StudySession{}, err
+		return err
 	}
 	if rows == 0 {
-		return StudySession{}, ErrNotFound
+		return r.missingOrConflict(userID, id)
 	}
 
-	return session, nil
+	return nil
 }
 
-func (r *SQLSessionRepository) Delete(userID, id string) error {
-	const query = `DELETE FROM study_sessions WHERE id = ? AND user_id = ?;`
+// Restore brings a deleted session back.
+func (r *SQLSessionRepository) Restore(userID, id string, version int) error {
+	const query = `
+		UPDATE study_sessions
+		SET deleted_at = NULL, updated_at = ?, version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL AND (? = 0 OR version = ?);
+	`
 
-	res, err := r.db.ExecContext(context.Background(), r.rebind(query), id, userID)
+	res, err := r.db.ExecContext(context.Background(), r.rebind(query), time.Now().UTC(), id, userID, version, version)
 	if err != nil {
 		return err
 	}
@@ -103,22 +212,123 @@ func (r *SQLSessionRepository) Delete(userID, id string) error {
 		return err
 	}
 	if rows == 0 {
-		return ErrNotFound
+		return r.deletedMissingOrConflict(userID, id)
 	}
 
 	return nil
 }
 
-func (r *SQLSessionRepository) List(userID string) ([]StudySession, error) {
+// PurgeDeleted permanently removes sessions deleted before the cutoff.
+func (r *SQLSessionRepository) PurgeDeleted(before time.Time) (int64, error) {
+	const query = `DELETE FROM study_sessions WHERE deleted_at IS NOT NULL AND deleted_at < ?;`
+
+	res, err := r.db.ExecContext(context.Background(), r.rebind(query), before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// missingOrConflict explains why a conditional write matched no rows.
+func (r *SQLSessionRepository) missingOrConflict(userID, id string) error {
+	const query = `SELECT COUNT(1) FROM study_sessions WHERE id = ? AND user_id = ? AND deleted_at IS NULL;`
+	return r.conflictIfExists(query, userID, id)
+}
+
+// deletedMissingOrConflict is missingOrConflict for rows in the trash.
+func (r *SQLSessionRepository) deletedMissingOrConflict(userID, id string) error {
+	const query = `SELECT COUNT(1) FROM study_sessions WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL;`
+	return r.conflictIfExists(query, userID, id)
+}
+
+func (r *SQLSessionRepository) conflictIfExists(query, userID, id string) error {
+	var count int
+	if err := r.db.QueryRowContext(context.Background(), r.rebind(query), id, userID).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrVersionConflict
+	}
+	return ErrNotFound
+}
+
+const sessionColumns = `id, user_id, subject_id, subject_name, notes, reflection,
+		       start_time, end_time, duration_minutes, created_at, updated_at, version, deleted_at,
+		       focus, mood, energy, difficulty, mode`
+
+func (r *SQLSessionRepository) List(userID string, filter SessionFilter) ([]StudySession, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM study_sessions
+		WHERE user_id = ? AND deleted_at IS NULL`
+	args := []any{userID}
+
+	if filter.SubjectID != "" {
+		query += `
+		  AND subject_id = ?`
+		args = append(args, filter.SubjectID)
+	}
+	if len(filter.Tags) > 0 {
+		query += `
+		  AND id IN (
+		    SELECT st.session_id
+		    FROM session_tags st
+		    JOIN tags t ON t.id = st.tag_id
+		    WHERE t.user_id = ? AND t.name IN (?` + strings.Repeat(", ?", len(filter.Tags)-1) + `)
+		    GROUP BY st.session_id
+		    HAVING COUNT(*) = ?
+		  )`
+		args = append(args, userID)
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
+		}
+		args = append(args, len(filter.Tags))
+	}
+
+	return r.query(query+`
+		ORDER BY start_time DESC;`, args...)
+}
+
+// Overlapping returns live sessions whose span intersects [start, end),
+// other than excludeID, earliest first.
+func (r *SQLSessionRepository) Overlapping(userID, excludeID string, start, end time.Time) ([]StudySession, error) {
 	const query = `
-		SELECT id, user_id, subject_id, subject_name, notes, reflection,
-		       start_time, end_time, duration_minutes, created_at, updated_at
+		SELECT ` + sessionColumns + `
 		FROM study_sessions
-		WHERE user_id = ?
-		ORDER BY start_time DESC;
+		WHERE user_id = ? AND start_time < ? AND end_time > ? AND id <> ? AND deleted_at IS NULL
+		ORDER BY start_time ASC;
 	`
 
-	rows, err := r.db.QueryContext(context.Background(), r.rebind(query), userID)
+	return r.query(query, userID, end.UTC(), start.UTC(), excludeID)
+}
+
+// ChangedSince returns sessions created, updated or deleted after since,
+// including deleted ones so callers can emit tombstones.
+func (r *SQLSessionRepository) ChangedSince(userID string, since time.Time) ([]StudySession, error) {
+	const query = `
+		SELECT ` + sessionColumns + `
+		FROM study_sessions
+		WHERE user_id = ? AND updated_at > ?
+		ORDER BY updated_at ASC;
+	`
+
+	return r.query(query, userID, since.UTC())
+}
+
+// ListDeleted returns the user's trashed sessions, most recently deleted first.
+func (r *SQLSessionRepository) ListDeleted(userID string) ([]StudySession, error) {
+	const query = `
+		SELECT ` + sessionColumns + `
+		FROM study_sessions
+		WHERE user_id = ? AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC;
+	`
+
+	return r.query(query, userID)
+}
+
+func (r *SQLSessionRepository) query(query string, args ...any) ([]StudySession, error) {
+	rows, err := r.db.QueryContext(context.Background(), r.rebind(query), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -126,38 +336,10 @@ func (r *SQLSessionRepository) List(userID string) ([]StudySession, error) {
 
 	var sessions []StudySession
 	for rows.Next() {
-		var session StudySession
-		var notes sql.NullString
-		var reflection sql.NullString
-		var start, end, created, updated time.Time
-
-		if err := rows.Scan(
-			&session.ID,
-			&session.UserID,
-			&session.SubjectID,
-			&session.Subject,
-			&notes,
-			&reflection,
-			&start,
-			&end,
-			&session.DurationMinutes,
-			&created,
-			&updated,
-		); err != nil {
+		session, err := scanSession(rows)
+		if err != nil {
 			return nil, err
 		}
-
-		if notes.Valid {
-			session.Notes = notes.String
-		}
-		if reflection.Valid {
-			session.Reflection = reflection.String
-		}
-		session.StartTime = start.UTC()
-		session.EndTime = end.UTC()
-		session.CreatedAt = created.UTC()
-		session.LastUpdated = updated.UTC()
-
 		sessions = append(sessions, session)
 	}
 
@@ -165,13 +347,215 @@ func (r *SQLSessionRepository) List(userID string) ([]StudySession, error) {
 		return nil, err
 	}
 
+	if err := r.loadDetails(sessions); err != nil {
+		return nil, err
+	}
 	return sessions, nil
 }
 
+// loadDetails fills in the tags and segments of sessions, which all belong
+// to one user.
+func (r *SQLSessionRepository) loadDetails(sessions []StudySession) error {
+	if err := r.loadTags(sessions); err != nil {
+		return err
+	}
+	return r.loadSegments(sessions)
+}
+
+func (r *SQLSessionRepository) loadSegments(sessions []StudySession) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT seg.session_id, seg.kind, seg.start_time, seg.end_time, seg.planned_minutes, seg.interrupted
+		FROM session_segments seg
+		JOIN study_sessions ss ON ss.id = seg.session_id
+		WHERE ss.user_id = ?`
+	args := []any{sessions[0].UserID}
+	if len(sessions) == 1 {
+		query += ` AND seg.session_id = ?`
+		args = append(args, sessions[0].ID)
+	}
+
+	rows, err := r.db.QueryContext(context.Background(), r.rebind(query+` ORDER BY seg.session_id, seg.position;`), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	bySession := make(map[string][]Segment)
+	for rows.Next() {
+		var sessionID string
+		var seg Segment
+		if err := rows.Scan(&sessionID, &seg.Kind, &seg.StartTime, &seg.EndTime, &seg.PlannedMinutes, &seg.Interrupted); err != nil {
+			return err
+		}
+		seg.StartTime = seg.StartTime.UTC()
+		seg.EndTime = seg.EndTime.UTC()
+		bySession[sessionID] = append(bySession[sessionID], seg)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range sessions {
+		sessions[i].Segments = bySession[sessions[i].ID]
+	}
+	return nil
+}
+
+// loadTags fills in the tags of sessions, which all belong to one user.
+func (r *SQLSessionRepository) loadTags(sessions []StudySession) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT st.session_id, t.name
+		FROM session_tags st
+		JOIN tags t ON t.id = st.tag_id
+		WHERE t.user_id = ?`
+	args := []any{sessions[0].UserID}
+	if len(sessions) == 1 {
+		query += ` AND st.session_id = ?`
+		args = append(args, sessions[0].ID)
+	}
+
+	rows, err := r.db.QueryContext(context.Background(), r.rebind(query+` ORDER BY t.name;`), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	bySession := make(map[string][]string)
+	for rows.Next() {
+		var sessionID, name string
+		if err := rows.Scan(&sessionID, &name); err != nil {
+			return err
+		}
+		bySession[sessionID] = append(bySession[sessionID], name)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range sessions {
+		sessions[i].Tags = bySession[sessions[i].ID]
+		if sessions[i].Tags == nil {
+			sessions[i].Tags = []string{}
+		}
+	}
+	return nil
+}
+
+func (r *SQLSessionRepository) Get(userID, id string) (StudySession, error) {
+	const query = `
+		SELECT ` + sessionColumns + `
+		FROM study_sessions
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL;
+	`
+
+	session, err := scanSession(r.db.QueryRowContext(context.Background(), r.rebind(query), id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return StudySession{}, ErrNotFound
+		}
+		return StudySession{}, err
+	}
+
+	sessions := []StudySession{session}
+	if err := r.loadDetails(sessions); err != nil {
+		return StudySession{}, err
+	}
+	return sessions[0], nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSession reads a row selected with sessionColumns.
+func scanSession(row rowScanner) (StudySession, error) {
+	var session StudySession
+	var notes sql.NullString
+	var reflection sql.NullString
+	var start, end, created, updated time.Time
+	var deleted sql.NullTime
+	var focus, mood, energy, difficulty sql.NullInt64
+
+	if err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.SubjectID,
+		&session.Subject,
+		&notes,
+		&reflection,
+		&start,
+		&end,
+		&session.DurationMinutes,
+		&created,
+		&updated,
+		&session.Version,
+		&deleted,
+		&focus,
+		&mood,
+		&energy,
+		&difficulty,
+		&session.Mode,
+	); err != nil {
+		return StudySession{}, err
+	}
+
+	if notes.Valid {
+		session.Notes = notes.String
+	}
+	if reflection.Valid {
+		session.Reflection = reflection.String
+	}
+	session.StartTime = start.UTC()
+	session.EndTime = end.UTC()
+	session.CreatedAt = created.UTC()
+	session.LastUpdated = updated.UTC()
+	if deleted.Valid {
+		deletedAt := deleted.Time.UTC()
+		session.DeletedAt = &deletedAt
+	}
+	session.Focus = intOrNil(focus)
+	session.Mood = intOrNil(mood)
+	session.Energy = intOrNil(energy)
+	session.Difficulty = intOrNil(difficulty)
+
+	return session, nil
+}
+
 func (r *SQLSessionRepository) rebind(query string) string {
 	return database.Rebind(query, r.useDollar)
 }
 
+func modeOrDefault(mode string) string {
+	if mode == "" {
+		return ModeManual
+	}
+	return mode
+}
+
+func nullIfNil(value *int) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*value), Valid: true}
+}
+
+func intOrNil(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	v := int(value.Int64)
+	return &v
+}
+
 func nullIfEmpty(value string) sql.NullString {
 	if strings.TrimSpace(value) == "" {
 		return sql.NullString{}
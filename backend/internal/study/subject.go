@@ -3,13 +3,44 @@ package study
 import "time"
 
 // Subject represents a study category users can select sessions for.
+// Subjects form a tree through ParentID; top-level subjects have none.
 type Subject struct {
 	ID           string    `json:"id"`
 	UserID       string    `json:"userId"`
 	Name         string    `json:"name"`
 	Color        string    `json:"color"`
+	ParentID     string    `json:"parentId,omitempty"`
+	Archived     bool      `json:"archived"`
 	SessionCount int       `json:"sessionCount"`
 	TotalMinutes int       `json:"totalMinutes"`
 	CreatedAt    time.Time `json:"createdAt"`
 	UpdatedAt    time.Time `json:"updatedAt"`
+	Version      int       `json:"version"`
+	// Rollup totals add the subject's descendants to its own totals. They
+	// are only filled in by List.
+	RollupSessionCount int `json:"rollupSessionCount"`
+	RollupMinutes      int `json:"rollupMinutes"`
+	// ArchivedAt is set while the subject is archived. Archived subjects keep
+	// their history but cannot take new sessions.
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
+	// DeletedAt is set on deleted subjects, which only appear in sync results.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// SubjectFilter narrows subject listings. Archived subjects are left out
+// unless IncludeArchived is set.
+type SubjectFilter struct {
+	IncludeArchived bool
+}
+
+// SubjectNode is a subject with its children, as returned by SubjectTree.
+type SubjectNode struct {
+	Subject
+	Children []SubjectNode `json:"children"`
+}
+
+// MoveSubjectRequest names a subject's new parent; empty moves it to the
+// top level.
+type MoveSubjectRequest struct {
+	ParentID string `json:"parentId"`
 }
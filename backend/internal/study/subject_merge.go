@@ -0,0 +1,221 @@
+package study
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// duplicateThreshold is the lowest edit-distance similarity reported as a
+// likely duplicate.
+const duplicateThreshold = 0.8
+
+// Reasons a pair of subjects is suggested as duplicates.
+const (
+	DuplicateSameName     = "same-name"
+	DuplicateAbbreviation = "abbreviation"
+	DuplicateInitials     = "initials"
+	DuplicateSimilar      = "similar"
+)
+
+// DuplicateSuggestion is a pair of subjects that probably mean the same
+// thing. Target has more sessions and is the suggested merge target.
+type DuplicateSuggestion struct {
+	Source Subject `json:"source"`
+	Target Subject `json:"target"`
+	Reason string  `json:"reason"`
+	// Score runs from 0 to 1, higher meaning more alike.
+	Score float64 `json:"score"`
+}
+
+// MergeSubject folds source into target. Every session of source, trashed
+// ones included, moves to target under target's name; source's children
+// move under target; target takes source's colour if it has none; source
+// goes to the trash. Live sessions that move get an update revision.
+func (s *Service) MergeSubject(ctx context.Context, userID, sourceID, targetID string) (Subject, error) {
+	if s.subjects == nil {
+		return Subject{}, errors.New("subject repository not configured")
+	}
+	if sourceID == targetID {
+		return Subject{}, ErrSubjectMergeSelf
+	}
+
+	var merged Subject
+	err := s.atomically(ctx, func(scoped *Service) error {
+		source, err := scoped.subjects.Get(userID, sourceID)
+		if err != nil {
+			return err
+		}
+		target, err := scoped.subjects.Get(userID, targetID)
+		if err != nil {
+			return err
+		}
+
+		// A target below source first takes source's place in the tree, so
+		// moving source's children under it cannot form a cycle.
+		if err := scoped.checkParent(userID, sourceID, target.ParentID); errors.Is(err, ErrSubjectCycle) {
+			if target, err = scoped.subjects.Move(userID, targetID, source.ParentID, 0); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+		if target.Color == "" && source.Color != "" {
+			target.Color = source.Color
+			target.UpdatedAt = time.Now().UTC()
+			target.Version = 0
+			if target, err = scoped.subjects.Update(target); err != nil {
+				return err
+			}
+		}
+
+		if err := scoped.resubjectSessions(userID, sourceID, target); err != nil {
+			return err
+		}
+		if err := scoped.subjects.Merge(userID, sourceID, targetID); err != nil {
+			return err
+		}
+
+		// List, unlike Get, fills in the totals.
+		subjects, err := scoped.subjects.List(userID)
+		if err != nil {
+			return err
+		}
+		for _, subject := range subjects {
+			if subject.ID == targetID {
+				merged = subject
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Subject{}, err
+	}
+	s.logger.InfoContext(ctx, "subjects merged", "userId", userID, "sourceId", sourceID, "targetId", targetID)
+	return merged, nil
+}
+
+// SubjectDuplicates suggests pairs of the user's subjects that look like
+// the same subject under different names, most alike first. Archived
+// subjects are included.
+func (s *Service) SubjectDuplicates(ctx context.Context, userID string) ([]DuplicateSuggestion, error) {
+	subjects, err := s.ListSubjects(ctx, userID, SubjectFilter{IncludeArchived: true})
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := []DuplicateSuggestion{}
+	for i := range subjects {
+		for j := i + 1; j < len(subjects); j++ {
+			reason, score := compareSubjectNames(subjects[i].Name, subjects[j].Name)
+			if reason == "" {
+				continue
+			}
+			source, target := subjects[i], subjects[j]
+			if source.SessionCount > target.SessionCount ||
+				(source.SessionCount == target.SessionCount && source.CreatedAt.Before(target.CreatedAt)) {
+				source, target = target, source
+			}
+			suggestions = append(suggestions, DuplicateSuggestion{Source: source, Target: target, Reason: reason, Score: score})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	return suggestions, nil
+}
+
+// compareSubjectNames reports why two names look like duplicates, or an
+// empty reason when they do not.
+func compareSubjectNames(a, b string) (string, float64) {
+	wordsA, wordsB := nameWords(a), nameWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return "", 0
+	}
+	joinedA, joinedB := strings.Join(wordsA, " "), strings.Join(wordsB, " ")
+	switch {
+	case joinedA == joinedB:
+		return DuplicateSameName, 1
+	case abbreviates(wordsA, wordsB) || abbreviates(wordsB, wordsA):
+		return DuplicateAbbreviation, 0.9
+	case initials(wordsA, wordsB) || initials(wordsB, wordsA):
+		return DuplicateInitials, 0.85
+	}
+	if score := similarity(joinedA, joinedB); score >= duplicateThreshold {
+		return DuplicateSimilar, math.Round(score*100) / 100
+	}
+	return "", 0
+}
+
+// nameWords lowercases a name and splits it into words, dropping
+// punctuation.
+func nameWords(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// abbreviates reports whether each of short's words starts the matching
+// word of long, as in "Lin Alg" and "Linear Algebra".
+func abbreviates(short, long []string) bool {
+	if len(short) != len(long) {
+		return false
+	}
+	for i := range short {
+		if !strings.HasPrefix(long[i], short[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// initials reports whether acronym is the initials of a multi-word name,
+// as in "LA" and "Linear Algebra".
+func initials(acronym, name []string) bool {
+	if len(acronym) != 1 || len(name) < 2 || len([]rune(acronym[0])) != len(name) {
+		return false
+	}
+	letters := []rune(acronym[0])
+	for i, word := range name {
+		if []rune(word)[0] != letters[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// similarity is one minus the edit distance over the longer length. The
+// distance counts swapping two adjacent letters as one edit, since that is
+// the most common typo.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	dist := make([][]int, len(ra)+1)
+	for i := range dist {
+		dist[i] = make([]int, len(rb)+1)
+		dist[i][0] = i
+	}
+	for j := range dist[0] {
+		dist[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			dist[i][j] = min(dist[i-1][j]+1, dist[i][j-1]+1, dist[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				dist[i][j] = min(dist[i][j], dist[i-2][j-2]+1)
+			}
+		}
+	}
+	return 1 - float64(dist[len(ra)][len(rb)])/float64(longest)
+}
@@ -0,0 +1,135 @@
+package study
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMergeSubject(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	target, err := svc.CreateSubject(ctx, testUser, Subject{Name: "Mathematics"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := svc.CreateSubject(ctx, testUser, Subject{Name: "Maths", Color: "#ff0000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := svc.CreateSubject(ctx, testUser, Subject{Name: "Algebra", ParentID: source.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := mustCreateSession(t, svc, "Maths", at(8, 0), at(9, 0))
+	trashed := mustCreateSession(t, svc, "Maths", at(10, 0), at(11, 0))
+	if err := svc.DeleteSession(ctx, testUser, trashed.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	mustCreateSession(t, svc, "Mathematics", at(12, 0), at(12, 30))
+
+	merged, err := svc.MergeSubject(ctx, testUser, source.ID, target.ID)
+	if err != nil {
+		t.Fatalf("MergeSubject: %v", err)
+	}
+	if merged.ID != target.ID || merged.SessionCount != 2 || merged.TotalMinutes != 90 {
+		t.Fatalf("merged = %s with %d sessions and %d minutes, want %s with 2 and 90", merged.ID, merged.SessionCount, merged.TotalMinutes, target.ID)
+	}
+	if merged.Color != "#ff0000" {
+		t.Fatalf("color = %q, want the source's colour for a target without one", merged.Color)
+	}
+
+	moved, err := svc.ListSessions(ctx, testUser, SessionFilter{SubjectID: target.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moved) != 2 {
+		t.Fatalf("target has %d live sessions, want 2", len(moved))
+	}
+	for _, session := range moved {
+		if session.Subject != "Mathematics" {
+			t.Errorf("session %s is named %q, want Mathematics", session.ID, session.Subject)
+		}
+	}
+	history, _ := svc.SessionHistory(ctx, testUser, live.ID)
+	if last := history[len(history)-1]; last.Action != RevisionUpdate || last.Before.SubjectID != source.ID || last.After.SubjectID != target.ID {
+		t.Fatalf("last revision = %s from %s to %s, want an update from the source to the target", last.Action, last.Before.SubjectID, last.After.SubjectID)
+	}
+
+	trash, err := svc.ListTrash(ctx, testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash.Sessions) != 1 || trash.Sessions[0].SubjectID != target.ID || trash.Sessions[0].Subject != "Mathematics" {
+		t.Fatalf("trashed sessions = %+v, want %s moved to the target", trash.Sessions, trashed.ID)
+	}
+	if len(trash.Subjects) != 1 || trash.Subjects[0].ID != source.ID {
+		t.Fatalf("trashed subjects = %+v, want the source", trash.Subjects)
+	}
+
+	subjects, err := svc.ListSubjects(ctx, testUser, SubjectFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, subject := range subjects {
+		if subject.ID == child.ID && subject.ParentID != target.ID {
+			t.Fatalf("child parent = %q, want %s", subject.ParentID, target.ID)
+		}
+	}
+}
+
+func TestMergeSubjectIntoItsDescendant(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	parent, err := svc.CreateSubject(ctx, testUser, Subject{Name: "Science"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := svc.CreateSubject(ctx, testUser, Subject{Name: "Physics", ParentID: parent.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := svc.CreateSubject(ctx, testUser, Subject{Name: "Mechanics", ParentID: source.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The target takes the source's place instead of ending up below itself.
+	merged, err := svc.MergeSubject(ctx, testUser, source.ID, target.ID)
+	if err != nil {
+		t.Fatalf("MergeSubject: %v", err)
+	}
+	if merged.ParentID != parent.ID {
+		t.Fatalf("parent = %q, want %s", merged.ParentID, parent.ID)
+	}
+}
+
+func TestMergeSubjectRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		source func(own, other Subject) string
+		target func(own, other Subject) string
+		want   error
+	}{
+		{name: "itself", source: func(own, _ Subject) string { return own.ID }, target: func(own, _ Subject) string { return own.ID }, want: ErrSubjectMergeSelf},
+		{name: "an unknown source", source: func(_, _ Subject) string { return "missing" }, target: func(own, _ Subject) string { return own.ID }, want: ErrSubjectNotFound},
+		{name: "another user's target", source: func(own, _ Subject) string { return own.ID }, target: func(_, other Subject) string { return other.ID }, want: ErrSubjectNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			ctx := context.Background()
+			own, err := svc.CreateSubject(ctx, testUser, Subject{Name: "Physics"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			other, err := svc.CreateSubject(ctx, otherUser, Subject{Name: "Chemistry"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := svc.MergeSubject(ctx, testUser, tt.source(own, other), tt.target(own, other)); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -13,27 +13,30 @@ import (
 
 // SQLSubjectRepository persists subjects to SQLite.
 type SQLSubjectRepository struct {
-	db        *sql.DB
+	db        database.Executor
 	useDollar bool
+	logger    *slog.Logger
 }
 
 // NewSQLSubjectRepository returns a SubjectRepository backed by SQLite.
-func NewSQLSubjectRepository(db *sql.DB) *SQLSubjectRepository {
+func NewSQLSubjectRepository(db *sql.DB, logger *slog.Logger) *SQLSubjectRepository {
 	return &SQLSubjectRepository{
 		db:        db,
 		useDollar: database.UsesDollarPlaceholders(db),
+		logger:    logger,
 	}
 }
 
+// Create inserts a subject. A name already taken by a live subject fails
+// with ErrSubjectNameExists and one taken by a subject in the trash with
+// ErrSubjectInTrash; the existing row is never changed. The conflict is
+// skipped rather than raised so a surrounding transaction stays usable.
 func (r *SQLSubjectRepository) Create(subject Subject) (Subject, error) {
-	const query = `
-		INSERT INTO subjects (id, user_id, name, color, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-		ON CONFLICT(name) DO UPDATE SET
-			user_id = excluded.user_id,
-			color = excluded.color,
-			updated_at = excluded.updated_at
-		RETURNING id, user_id, name, color, created_at, updated_at;
+	query := `
+		INSERT INTO subjects (id, user_id, name, color, parent_id, created_at, updated_at, version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 1)
+		ON CONFLICT(name) DO NOTHING
+		RETURNING ` + subjectColumns("") + `;
 	`
 
 	row := r.db.QueryRowContext(
@@ -43,28 +46,49 @@ func (r *SQLSubjectRepository) Create(subject Subject) (Subject, error) {
 		subject.UserID,
 		subject.Name,
 		nullIfEmpty(subject.Color),
+		nullIfEmpty(subject.ParentID),
 		subject.CreatedAt.UTC(),
 		subject.UpdatedAt.UTC(),
 	)
-	var created Subject
-	var color sql.NullString
-	if err := row.Scan(&created.ID, &created.UserID, &created.Name, &color, &created.CreatedAt, &created.UpdatedAt); err != nil {
+	created, err := scanSubject(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Subject{}, r.nameConflict(subject.UserID, subject.Name)
+		}
 		return Subject{}, mapSubjectError(err)
 	}
-	if color.Valid {
-		created.Color = color.String
-	}
 	return created, nil
 }
 
+// nameConflict tells whether the user's subject holding name is live or
+// trashed. Another user's subject is reported as a plain name clash so its
+// trash state does not leak.
+func (r *SQLSubjectRepository) nameConflict(userID, name string) error {
+	const query = `SELECT deleted_at IS NOT NULL FROM subjects WHERE name = ? AND user_id = ?;`
+
+	var trashed bool
+	if err := r.db.QueryRowContext(context.Background(), r.rebind(query), name, userID).Scan(&trashed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Another user holds the name, or the holder was purged in between.
+			return ErrSubjectNameExists
+		}
+		return err
+	}
+	if trashed {
+		return ErrSubjectInTrash
+	}
+	return ErrSubjectNameExists
+}
+
 func (r *SQLSubjectRepository) Update(subject Subject) (Subject, error) {
 	const query = `
 		UPDATE subjects
-		SET name = ?, color = ?, updated_at = ?
-		WHERE id = ? AND user_id = ?;
+		SET name = ?, color = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)
+		RETURNING version;
 	`
 
-	res, err := r.db.ExecContext(
+	err := r.db.QueryRowContext(
 		context.Background(),
 		r.rebind(query),
 		subject.Name,
@@ -72,26 +96,109 @@ func (r *SQLSubjectRepository) Update(subject Subject) (Subject, error) {
 		subject.UpdatedAt.UTC(),
 		subject.ID,
 		subject.UserID,
-	)
+		subject.Version,
+		subject.Version,
+	).Scan(&subject.Version)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Subject{}, r.missingOrConflict(subject.UserID, subject.ID)
+		}
 		return Subject{}, mapSubjectError(err)
 	}
 
+	return subject, nil
+}
+
+// Move sets a subject's parent; an empty parentID makes it top-level.
+// Callers check that the move does not create a cycle.
+func (r *SQLSubjectRepository) Move(userID, id, parentID string, version int) (Subject, error) {
+	const query = `
+		UPDATE subjects
+		SET parent_id = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?);
+	`
+
+	res, err := r.db.ExecContext(context.Background(), r.rebind(query), nullIfEmpty(parentID), time.Now().UTC(), id, userID, version, version)
+	if err != nil {
+		return Subject{}, err
+	}
 	rows, err := res.RowsAffected()
 	if err != nil {
 		return Subject{}, err
 	}
 	if rows == 0 {
-		return Subject{}, ErrSubjectNotFound
+		return Subject{}, r.missingOrConflict(userID, id)
 	}
+	return r.Get(userID, id)
+}
 
-	return subject, nil
+// SetArchived archives or unarchives a subject. Archiving an archived
+// subject keeps its original archive time.
+func (r *SQLSubjectRepository) SetArchived(userID, id string, archived bool, version int) (Subject, error) {
+	const query = `
+		UPDATE subjects
+		SET archived_at = CASE WHEN ? THEN COALESCE(archived_at, ?) ELSE NULL END,
+		    updated_at = ?, version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?);
+	`
+
+	now := time.Now().UTC()
+	res, err := r.db.ExecContext(context.Background(), r.rebind(query), archived, now, now, id, userID, version, version)
+	if err != nil {
+		return Subject{}, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return Subject{}, err
+	}
+	if rows == 0 {
+		return Subject{}, r.missingOrConflict(userID, id)
+	}
+	return r.Get(userID, id)
 }
 
-func (r *SQLSubjectRepository) Delete(userID, id string) error {
-	const query = `DELETE FROM subjects WHERE id = ? AND user_id = ?;`
+// Merge moves source's children to target and moves source to the trash.
+// Its sessions are moved beforehand with ReassignSessions.
+func (r *SQLSubjectRepository) Merge(userID, sourceID, targetID string) error {
+	const query = `
+		UPDATE subjects
+		SET parent_id = ?, updated_at = ?, version = version + 1
+		WHERE user_id = ? AND parent_id = ? AND id <> ?;
+	`
 
-	res, err := r.db.ExecContext(context.Background(), r.rebind(query), id, userID)
+	if _, err := r.db.ExecContext(context.Background(), r.rebind(query), targetID, time.Now().UTC(), userID, sourceID, targetID); err != nil {
+		return err
+	}
+	return r.Delete(userID, sourceID, 0)
+}
+
+// ReassignSessions points every session of fromID, trashed ones included,
+// at subject to and copies its name, in one statement. Live sessions are
+// stamped with at and get a new version so sync clients pick them up.
+func (r *SQLSubjectRepository) ReassignSessions(userID, fromID string, to Subject, at time.Time) error {
+	const query = `
+		UPDATE study_sessions
+		SET subject_id = ?, subject_name = ?,
+		    updated_at = CASE WHEN deleted_at IS NULL THEN ? ELSE updated_at END,
+		    version = CASE WHEN deleted_at IS NULL THEN version + 1 ELSE version END
+		WHERE user_id = ? AND subject_id = ?;
+	`
+
+	_, err := r.db.ExecContext(context.Background(), r.rebind(query), to.ID, to.Name, at.UTC(), userID, fromID)
+	return err
+}
+
+// Delete marks the subject deleted. The row stays behind as a tombstone for
+// sync, and its sessions keep their subject_id.
+func (r *SQLSubjectRepository) Delete(userID, id string, version int) error {
+	const query = `
+		UPDATE subjects
+		SET deleted_at = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?);
+	`
+
+	now := time.Now().UTC()
+	res, err := r.db.ExecContext(context.Background(), r.rebind(query), now, now, id, userID, version, version)
 	if err != nil {
 		return err
 	}
@@ -101,20 +208,134 @@ func (r *SQLSubjectRepository) Delete(userID, id string) error {
 		return err
 	}
 	if rows == 0 {
-		return ErrSubjectNotFound
+		return r.missingOrConflict(userID, id)
 	}
 
 	return nil
 }
 
-func (r *SQLSubjectRepository) List(userID string) ([]Subject, error) {
+// Restore brings a deleted subject back. Its sessions never lost their
+// subject_id, so they are linked to it again. A subject whose parent is
+// still deleted comes back at the top level.
+func (r *SQLSubjectRepository) Restore(userID, id string, version int) error {
 	const query = `
-		SELECT s.id, s.user_id, s.name, s.color, s.created_at, s.updated_at,
+		UPDATE subjects
+		SET deleted_at = NULL, updated_at = ?, version = version + 1,
+		    parent_id = CASE WHEN EXISTS (
+		      SELECT 1 FROM subjects p WHERE p.id = subjects.parent_id AND p.deleted_at IS NULL
+		    ) THEN parent_id ELSE NULL END
+		WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL AND (? = 0 OR version = ?);
+	`
+
+	res, err := r.db.ExecContext(context.Background(), r.rebind(query), time.Now().UTC(), id, userID, version, version)
+	if err != nil {
+		return mapSubjectError(err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return r.deletedMissingOrConflict(userID, id)
+	}
+
+	return nil
+}
+
+// PurgeDeleted permanently removes subjects deleted before the cutoff that
+// no session references any more.
+func (r *SQLSubjectRepository) PurgeDeleted(before time.Time) (int64, error) {
+	const query = `
+		DELETE FROM subjects
+		WHERE deleted_at IS NOT NULL AND deleted_at < ?
+		  AND NOT EXISTS (SELECT 1 FROM study_sessions ss WHERE ss.subject_id = subjects.id);
+	`
+
+	res, err := r.db.ExecContext(context.Background(), r.rebind(query), before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// missingOrConflict explains why a conditional write matched no rows.
+func (r *SQLSubjectRepository) missingOrConflict(userID, id string) error {
+	const query = `SELECT COUNT(1) FROM subjects WHERE id = ? AND user_id = ? AND deleted_at IS NULL;`
+	return r.conflictIfExists(query, userID, id)
+}
+
+// deletedMissingOrConflict is missingOrConflict for rows in the trash.
+func (r *SQLSubjectRepository) deletedMissingOrConflict(userID, id string) error {
+	const query = `SELECT COUNT(1) FROM subjects WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL;`
+	return r.conflictIfExists(query, userID, id)
+}
+
+func (r *SQLSubjectRepository) conflictIfExists(query, userID, id string) error {
+	var count int
+	if err := r.db.QueryRowContext(context.Background(), r.rebind(query), id, userID).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrVersionConflict
+	}
+	return ErrSubjectNotFound
+}
+
+// List returns the user's live subjects, archived ones included, with their
+// own totals and rollup totals that include every descendant.
+func (r *SQLSubjectRepository) List(userID string) ([]Subject, error) {
+	subjects, err := r.listWithTotals(`s.user_id = ? AND s.deleted_at IS NULL`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(subjects))
+	for i := range subjects {
+		index[subjects[i].ID] = i
+		subjects[i].RollupSessionCount = subjects[i].SessionCount
+		subjects[i].RollupMinutes = subjects[i].TotalMinutes
+	}
+	for _, subject := range subjects {
+		// The seen guard stops at a cycle should one ever be stored.
+		seen := map[string]bool{subject.ID: true}
+		for parent := subject.ParentID; parent != "" && !seen[parent]; {
+			i, ok := index[parent]
+			if !ok {
+				break
+			}
+			seen[parent] = true
+			subjects[i].RollupSessionCount += subject.SessionCount
+			subjects[i].RollupMinutes += subject.TotalMinutes
+			parent = subjects[i].ParentID
+		}
+	}
+	return subjects, nil
+}
+
+// ChangedSince returns subjects created, updated or deleted after since,
+// including deleted ones so callers can emit tombstones.
+func (r *SQLSubjectRepository) ChangedSince(userID string, since time.Time) ([]Subject, error) {
+	return r.listWithTotals(`s.user_id = ? AND s.updated_at > ?`, userID, since.UTC())
+}
+
+// ListDeleted returns the user's trashed subjects with totals from their
+// live sessions.
+func (r *SQLSubjectRepository) ListDeleted(userID string) ([]Subject, error) {
+	return r.listWithTotals(`s.user_id = ? AND s.deleted_at IS NOT NULL`, userID)
+}
+
+// listWithTotals selects subjects matching where, with session totals from
+// their live sessions.
+func (r *SQLSubjectRepository) listWithTotals(where string, args ...any) ([]Subject, error) {
+	query := `
+		SELECT ` + subjectColumns("s") + `,
 		       COUNT(ss.id) AS session_count,
 		       COALESCE(SUM(ss.duration_minutes), 0) AS total_minutes
 		FROM subjects s
 		LEFT JOIN study_sessions ss
 		  ON ss.user_id = s.user_id
+		 AND ss.deleted_at IS NULL
 		 AND (
 		   ss.subject_id = s.id
 		   OR (
@@ -122,12 +343,12 @@ func (r *SQLSubjectRepository) List(userID string) ([]Subject, error) {
 		     AND LOWER(ss.subject_name) = LOWER(s.name)
 		   )
 		 )
-		WHERE s.user_id = ?
-		GROUP BY s.id, s.user_id, s.name, s.color, s.created_at, s.updated_at
+		WHERE ` + where + `
+		GROUP BY ` + subjectColumns("s") + `
 		ORDER BY LOWER(s.name) ASC;
 	`
 
-	rows, err := r.db.QueryContext(context.Background(), r.rebind(query), userID)
+	rows, err := r.db.QueryContext(context.Background(), r.rebind(query), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -135,30 +356,13 @@ func (r *SQLSubjectRepository) List(userID string) ([]Subject, error) {
 
 	var subjects []Subject
 	for rows.Next() {
-		var subject Subject
-		var color sql.NullString
-		var created, updated time.Time
 		var sessionCount sql.NullInt64
 		var totalMinutes sql.NullInt64
 
-		if err := rows.Scan(
-			&subject.ID,
-			&subject.UserID,
-			&subject.Name,
-			&color,
-			&created,
-			&updated,
-			&sessionCount,
-			&totalMinutes,
-		); err != nil {
+		subject, err := scanSubject(rows, &sessionCount, &totalMinutes)
+		if err != nil {
 			return nil, err
 		}
-
-		if color.Valid {
-			subject.Color = color.String
-		}
-		subject.CreatedAt = created.UTC()
-		subject.UpdatedAt = updated.UTC()
 		if sessionCount.Valid {
 			subject.SessionCount = int(sessionCount.Int64)
 		}
@@ -177,72 +381,91 @@ func (r *SQLSubjectRepository) List(userID string) ([]Subject, error) {
 }
 
 func (r *SQLSubjectRepository) Get(userID, id string) (Subject, error) {
-	const query = `
-		SELECT id, user_id, name, color, created_at, updated_at
+	query := `
+		SELECT ` + subjectColumns("") + `
 		FROM subjects
-		WHERE id = ? AND user_id = ?;
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL;
 	`
 
-	var subject Subject
-	var color sql.NullString
-	var created, updated time.Time
-	err := r.db.QueryRowContext(context.Background(), r.rebind(query), id, userID).Scan(
-		&subject.ID,
-		&subject.UserID,
-		&subject.Name,
-		&color,
-		&created,
-		&updated,
-	)
+	subject, err := scanSubject(r.db.QueryRowContext(context.Background(), r.rebind(query), id, userID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Subject{}, ErrSubjectNotFound
 		}
 		return Subject{}, err
 	}
-
-	if color.Valid {
-		subject.Color = color.String
-	}
-	subject.CreatedAt = created.UTC()
-	subject.UpdatedAt = updated.UTC()
-
 	return subject, nil
 }
 
 func (r *SQLSubjectRepository) GetByName(userID, name string) (Subject, error) {
-	const query = `
-		SELECT id, user_id, name, color, created_at, updated_at
+	query := `
+		SELECT ` + subjectColumns("") + `
 		FROM subjects
-		WHERE user_id = ? AND LOWER(name) = LOWER(?);
+		WHERE user_id = ? AND LOWER(name) = LOWER(?) AND deleted_at IS NULL;
 	`
 
+	subject, err := scanSubject(r.db.QueryRowContext(context.Background(), r.rebind(query), userID, name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Debug("subject not found by name", "userId", userID, "name", name)
+			return Subject{}, ErrSubjectNotFound
+		}
+		r.logger.Error("subject lookup by name failed", "userId", userID, "name", name, "error", err)
+		return Subject{}, err
+	}
+	return subject, nil
+}
+
+// subjectColumns lists the columns scanSubject reads, optionally qualified
+// with a table alias.
+func subjectColumns(alias string) string {
+	columns := []string{"id", "user_id", "name", "color", "parent_id", "created_at", "updated_at", "version", "deleted_at", "archived_at"}
+	if alias != "" {
+		for i, column := range columns {
+			columns[i] = alias + "." + column
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+// scanSubject reads a row selected with subjectColumns followed by extra.
+func scanSubject(row rowScanner, extra ...any) (Subject, error) {
 	var subject Subject
-	var color sql.NullString
+	var color, parent sql.NullString
 	var created, updated time.Time
-	err := r.db.QueryRowContext(context.Background(), r.rebind(query), userID, name).Scan(
+	var deleted, archived sql.NullTime
+
+	dest := []any{
 		&subject.ID,
 		&subject.UserID,
 		&subject.Name,
 		&color,
+		&parent,
 		&created,
 		&updated,
-	)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			log.Printf("GetByName: subject not found user=%s name=%s", userID, name)
-			return Subject{}, ErrSubjectNotFound
-		}
-		log.Printf("GetByName: query failed user=%s name=%s err=%v", userID, name, err)
+		&subject.Version,
+		&deleted,
+		&archived,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
 		return Subject{}, err
 	}
-	log.Printf("GetByName: success user=%s subjectID=%s", userID, subject.ID)
 
 	if color.Valid {
 		subject.Color = color.String
 	}
+	subject.ParentID = parent.String
 	subject.CreatedAt = created.UTC()
 	subject.UpdatedAt = updated.UTC()
+	if deleted.Valid {
+		deletedAt := deleted.Time.UTC()
+		subject.DeletedAt = &deletedAt
+	}
+	if archived.Valid {
+		archivedAt := archived.Time.UTC()
+		subject.Archived = true
+		subject.ArchivedAt = &archivedAt
+	}
 
 	return subject, nil
 }
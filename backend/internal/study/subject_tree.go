@@ -0,0 +1,107 @@
+package study
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// SubjectTree returns the user's subjects nested under their parents, each
+// level in alphabetical order. Rollup totals include every descendant.
+// Subjects whose parent is filtered out appear at the top level.
+func (s *Service) SubjectTree(ctx context.Context, userID string, filter SubjectFilter) ([]SubjectNode, error) {
+	subjects, err := s.ListSubjects(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(subjects))
+	for _, subject := range subjects {
+		known[subject.ID] = true
+	}
+	children := make(map[string][]Subject)
+	for _, subject := range subjects {
+		parent := subject.ParentID
+		if !known[parent] {
+			parent = ""
+		}
+		children[parent] = append(children[parent], subject)
+	}
+
+	// List is alphabetical, so each level already is.
+	var build func(parentID string) []SubjectNode
+	build = func(parentID string) []SubjectNode {
+		nodes := []SubjectNode{}
+		for _, subject := range children[parentID] {
+			nodes = append(nodes, SubjectNode{Subject: subject, Children: build(subject.ID)})
+		}
+		return nodes
+	}
+	return build(""), nil
+}
+
+// MoveSubject puts a subject under a new parent, or at the top level when
+// parentID is empty. Moving a subject below itself is rejected.
+func (s *Service) MoveSubject(ctx context.Context, userID, id, parentID string, version int) (Subject, error) {
+	if s.subjects == nil {
+		return Subject{}, errors.New("subject repository not configured")
+	}
+	parentID = strings.TrimSpace(parentID)
+
+	var moved Subject
+	err := s.atomically(ctx, func(scoped *Service) error {
+		if _, err := scoped.subjects.Get(userID, id); err != nil {
+			return err
+		}
+		if err := scoped.checkParent(userID, id, parentID); err != nil {
+			return err
+		}
+		var err error
+		moved, err = scoped.subjects.Move(userID, id, parentID, version)
+		return err
+	})
+	if err != nil {
+		return Subject{}, err
+	}
+	s.logger.InfoContext(ctx, "subject moved", "userId", userID, "subjectId", id, "parentId", parentID)
+	return moved, nil
+}
+
+// checkParent verifies that parentID names a live subject of the user and
+// that id is not among its ancestors.
+func (s *Service) checkParent(userID, id, parentID string) error {
+	seen := map[string]bool{}
+	for ancestor := parentID; ancestor != ""; {
+		if ancestor == id {
+			return ErrSubjectCycle
+		}
+		if seen[ancestor] {
+			break
+		}
+		seen[ancestor] = true
+		subject, err := s.subjects.Get(userID, ancestor)
+		if err != nil {
+			if errors.Is(err, ErrSubjectNotFound) && ancestor == parentID {
+				return ErrUnknownParent
+			}
+			return err
+		}
+		ancestor = subject.ParentID
+	}
+	return nil
+}
+
+// ancestorAt returns the ID of subjectID's ancestor at depth, where 1 is
+// the top level. Subjects shallower than depth map to themselves.
+func ancestorAt(parents map[string]string, subjectID string, depth int) string {
+	var path []string
+	seen := map[string]bool{}
+	for id := subjectID; id != "" && !seen[id]; id = parents[id] {
+		seen[id] = true
+		path = append(path, id)
+	}
+	if len(path) <= depth {
+		return subjectID
+	}
+	return path[len(path)-depth]
+}
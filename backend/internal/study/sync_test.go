@@ -0,0 +1,113 @@
+package study
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSyncFullSnapshot(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	kept := mustCreateSession(t, svc, "Physics", at(8, 0), at(9, 0))
+	deleted := mustCreateSession(t, svc, "Physics", at(10, 0), at(11, 0))
+	if err := svc.DeleteSession(ctx, testUser, deleted.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := svc.Sync(ctx, testUser, "")
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if !changes.Full || changes.Token == "" {
+		t.Fatalf("full = %v, token = %q; want a full snapshot with a token", changes.Full, changes.Token)
+	}
+	if len(changes.Sessions) != 1 || changes.Sessions[0].ID != kept.ID {
+		t.Fatalf("sessions = %+v, want only %s", changes.Sessions, kept.ID)
+	}
+	if len(changes.Subjects) != 1 || len(changes.DeletedSessions) != 0 {
+		t.Fatalf("subjects = %d, tombstones = %d; want 1 and 0", len(changes.Subjects), len(changes.DeletedSessions))
+	}
+}
+
+func TestSyncDelta(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	unchanged := mustCreateSession(t, svc, "Physics", at(8, 0), at(9, 0))
+	updated := mustCreateSession(t, svc, "Physics", at(10, 0), at(11, 0))
+	deleted := mustCreateSession(t, svc, "Math", at(12, 0), at(13, 0))
+	subjects, err := svc.ListSubjects(ctx, testUser, SubjectFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A cursor taken now, rather than one from Sync, leaves out the overlap
+	// window so the unchanged session is not repeated.
+	token := encodeSyncToken(time.Now().UTC())
+	time.Sleep(10 * time.Millisecond)
+
+	if _, err := svc.PatchSession(ctx, testUser, updated.ID, []byte(`{"notes":"reviewed"}`), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteSession(ctx, testUser, deleted.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	var math Subject
+	for _, subject := range subjects {
+		if subject.Name == "Math" {
+			math = subject
+		}
+	}
+	if err := svc.DeleteSubject(ctx, testUser, math.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := svc.Sync(ctx, testUser, token)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if changes.Full {
+		t.Fatal("delta sync reported a full snapshot")
+	}
+	if len(changes.Sessions) != 1 || changes.Sessions[0].ID != updated.ID {
+		t.Fatalf("sessions = %+v, want only %s (not %s)", changes.Sessions, updated.ID, unchanged.ID)
+	}
+	if len(changes.DeletedSessions) != 1 || changes.DeletedSessions[0].ID != deleted.ID {
+		t.Fatalf("deleted sessions = %+v, want a tombstone for %s", changes.DeletedSessions, deleted.ID)
+	}
+	if len(changes.DeletedSubjects) != 1 || changes.DeletedSubjects[0].ID != math.ID {
+		t.Fatalf("deleted subjects = %+v, want a tombstone for %s", changes.DeletedSubjects, math.ID)
+	}
+	if tombstone := changes.DeletedSessions[0]; tombstone.Version <= deleted.Version {
+		t.Fatalf("tombstone version = %d, want it past %d", tombstone.Version, deleted.Version)
+	}
+}
+
+func TestSyncRejectsBadTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{name: "not base64", token: "%%%", want: ErrInvalidSyncToken},
+		{name: "unknown format", token: "djI6MTIz", want: ErrInvalidSyncToken},
+		{name: "older than the trash", token: encodeSyncToken(time.Now().Add(-31 * 24 * time.Hour)), want: ErrSyncTokenExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			if _, err := svc.Sync(context.Background(), testUser, tt.token); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncTokenRoundTrip(t *testing.T) {
+	cursor := time.Date(2026, time.October, 1, 8, 30, 0, 123, time.UTC)
+	got, err := decodeSyncToken(encodeSyncToken(cursor))
+	if err != nil || !got.Equal(cursor) {
+		t.Fatalf("decoded %v, %v; want %v", got, err, cursor)
+	}
+}
@@ -0,0 +1,164 @@
+package study
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxTagLength   = 40
+	maxSessionTags = 20
+)
+
+// Tag is a free-form label that can be attached to any number of sessions.
+// Names are stored lower-cased with inner whitespace replaced by hyphens.
+type Tag struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"userId"`
+	Name         string    `json:"name"`
+	SessionCount int       `json:"sessionCount"`
+	TotalMinutes int       `json:"totalMinutes"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// SessionFilter narrows a session listing. Sessions must carry every tag in
+// Tags and, when SubjectID is set, belong to that subject.
+type SessionFilter struct {
+	Tags      []string
+	SubjectID string
+}
+
+const (
+	defaultTagLimit = 20
+	maxTagLimit     = 100
+)
+
+// ListTags returns the user's tags starting with query, most used first, for
+// autocomplete. An empty query lists every tag up to limit.
+func (s *Service) ListTags(ctx context.Context, userID, query string, limit int) ([]Tag, error) {
+	if limit <= 0 {
+		limit = defaultTagLimit
+	}
+	limit = min(limit, maxTagLimit)
+	prefix := strings.Join(strings.Fields(strings.ToLower(query)), "-")
+	return s.tags.List(userID, prefix, limit)
+}
+
+// RenameTag renames a tag on every session carrying it. Renaming onto an
+// existing tag fails with ErrTagNameExists; MergeTags combines them instead.
+func (s *Service) RenameTag(ctx context.Context, userID, id, name string) (Tag, error) {
+	name, err := normalizeTag(name)
+	if err != nil {
+		return Tag{}, err
+	}
+
+	var renamed Tag
+	err = s.atomically(ctx, func(scoped *Service) error {
+		tag, err := scoped.tags.Get(userID, id)
+		if err != nil {
+			return err
+		}
+		if tag.Name != name {
+			err := scoped.retag(userID, tag.Name, func() error {
+				return scoped.tags.Rename(userID, id, name)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		renamed, err = scoped.tags.Get(userID, id)
+		return err
+	})
+	if err != nil {
+		return Tag{}, err
+	}
+	s.logger.InfoContext(ctx, "tag renamed", "userId", userID, "tagId", id, "name", name)
+	return renamed, nil
+}
+
+// MergeTags moves every session tagged sourceID onto targetID and deletes
+// the source tag.
+func (s *Service) MergeTags(ctx context.Context, userID, sourceID, targetID string) (Tag, error) {
+	if sourceID == targetID {
+		return Tag{}, ErrTagMergeSelf
+	}
+
+	var merged Tag
+	err := s.atomically(ctx, func(scoped *Service) error {
+		source, err := scoped.tags.Get(userID, sourceID)
+		if err != nil {
+			return err
+		}
+		if _, err := scoped.tags.Get(userID, targetID); err != nil {
+			return err
+		}
+		err = scoped.retag(userID, source.Name, func() error {
+			return scoped.tags.Merge(userID, sourceID, targetID)
+		})
+		if err != nil {
+			return err
+		}
+		merged, err = scoped.tags.Get(userID, targetID)
+		return err
+	})
+	if err != nil {
+		return Tag{}, err
+	}
+	s.logger.InfoContext(ctx, "tags merged", "userId", userID, "sourceId", sourceID, "targetId", targetID)
+	return merged, nil
+}
+
+// retag runs change, which alters the sessions tagged name, and records an
+// update revision for each of them. s must already be scoped to a transaction.
+func (s *Service) retag(userID, name string, change func() error) error {
+	before, err := s.sessions.List(userID, SessionFilter{Tags: []string{name}})
+	if err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	for i := range before {
+		after, err := s.sessions.Get(userID, before[i].ID)
+		if err != nil {
+			return err
+		}
+		if err := s.recordRevision(userID, RevisionUpdate, &before[i], &after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeTag returns the canonical form of a tag name.
+func normalizeTag(name string) (string, error) {
+	name = strings.Join(strings.Fields(strings.ToLower(name)), "-")
+	if name == "" || len(name) > maxTagLength {
+		return "", ErrInvalidTag
+	}
+	return name, nil
+}
+
+// normalizeTags canonicalises, de-duplicates and sorts a session's tags.
+func normalizeTags(names []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(names))
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		tag, err := normalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxSessionTags {
+		return nil, ErrTooManyTags
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
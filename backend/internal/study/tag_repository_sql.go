@@ -0,0 +1,153 @@
+package study
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"studytracker/internal/platform/database"
+)
+
+// SQLTagRepository persists tags to SQLite.
+type SQLTagRepository struct {
+	db        database.Executor
+	useDollar bool
+}
+
+// NewSQLTagRepository returns a TagRepository backed by db.
+func NewSQLTagRepository(db *sql.DB) *SQLTagRepository {
+	return &SQLTagRepository{
+		db:        db,
+		useDollar: database.UsesDollarPlaceholders(db),
+	}
+}
+
+func (r *SQLTagRepository) List(userID, prefix string, limit int) ([]Tag, error) {
+	pattern := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+	return r.listWithTotals(`t.user_id = ? AND t.name LIKE ? ESCAPE '\'`, limit, userID, pattern)
+}
+
+func (r *SQLTagRepository) Get(userID, id string) (Tag, error) {
+	tags, err := r.listWithTotals(`t.user_id = ? AND t.id = ?`, 1, userID, id)
+	if err != nil {
+		return Tag{}, err
+	}
+	if len(tags) == 0 {
+		return Tag{}, ErrTagNotFound
+	}
+	return tags[0], nil
+}
+
+// listWithTotals selects tags matching where, with totals from their live
+// sessions, most used first.
+func (r *SQLTagRepository) listWithTotals(where string, limit int, args ...any) ([]Tag, error) {
+	query := `
+		SELECT t.id, t.user_id, t.name, t.created_at,
+		       COUNT(ss.id) AS session_count,
+		       COALESCE(SUM(ss.duration_minutes), 0) AS total_minutes
+		FROM tags t
+		LEFT JOIN session_tags st ON st.tag_id = t.id
+		LEFT JOIN study_sessions ss ON ss.id = st.session_id AND ss.deleted_at IS NULL
+		WHERE ` + where + `
+		GROUP BY t.id, t.user_id, t.name, t.created_at
+		ORDER BY COUNT(ss.id) DESC, t.name ASC
+		LIMIT ?;
+	`
+
+	rows, err := r.db.QueryContext(context.Background(), r.rebind(query), append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+	for rows.Next() {
+		var tag Tag
+		var created time.Time
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &created, &tag.SessionCount, &tag.TotalMinutes); err != nil {
+			return nil, err
+		}
+		tag.CreatedAt = created.UTC()
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (r *SQLTagRepository) Rename(userID, id, name string) error {
+	if err := r.touchSessions(id); err != nil {
+		return err
+	}
+
+	const query = `UPDATE tags SET name = ? WHERE id = ? AND user_id = ?;`
+	res, err := r.db.ExecContext(context.Background(), r.rebind(query), name, id, userID)
+	if err != nil {
+		return mapTagError(err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// Merge moves every session from source to target and deletes source.
+func (r *SQLTagRepository) Merge(userID, sourceID, targetID string) error {
+	if err := r.touchSessions(sourceID); err != nil {
+		return err
+	}
+
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{`INSERT INTO session_tags (session_id, tag_id)
+		  SELECT session_id, ? FROM session_tags WHERE tag_id = ?
+		  ON CONFLICT (session_id, tag_id) DO NOTHING;`, []any{targetID, sourceID}},
+		{`DELETE FROM session_tags WHERE tag_id = ?;`, []any{sourceID}},
+		{`DELETE FROM tags WHERE id = ? AND user_id = ?;`, []any{sourceID, userID}},
+	}
+	for _, stmt := range statements {
+		if _, err := r.db.ExecContext(context.Background(), r.rebind(stmt.query), stmt.args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// touchSessions bumps the live sessions carrying a tag so that sync and
+// If-Match see their changed tag list.
+func (r *SQLTagRepository) touchSessions(tagID string) error {
+	const query = `
+		UPDATE study_sessions
+		SET updated_at = ?, version = version + 1
+		WHERE deleted_at IS NULL
+		  AND id IN (SELECT session_id FROM session_tags WHERE tag_id = ?);
+	`
+
+	_, err := r.db.ExecContext(context.Background(), r.rebind(query), time.Now().UTC(), tagID)
+	return err
+}
+
+func mapTagError(err error) error {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed: tags.") {
+		return ErrTagNameExists
+	}
+	if strings.Contains(err.Error(), "duplicate key value") && strings.Contains(err.Error(), "tags_") {
+		return ErrTagNameExists
+	}
+	return err
+}
+
+func (r *SQLTagRepository) rebind(query string) string {
+	return database.Rebind(query, r.useDollar)
+}
@@ -0,0 +1,75 @@
+package study
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"studytracker/internal/platform/database"
+)
+
+// SQLTransactor implements Transactor with database/sql transactions.
+type SQLTransactor struct {
+	db        *sql.DB
+	useDollar bool
+	logger    *slog.Logger
+}
+
+// NewSQLTransactor returns a Transactor for db.
+func NewSQLTransactor(db *sql.DB, logger *slog.Logger) *SQLTransactor {
+	return &SQLTransactor{
+		db:        db,
+		useDollar: database.UsesDollarPlaceholders(db),
+		logger:    logger,
+	}
+}
+
+func (t *SQLTransactor) InTx(ctx context.Context, fn func(tx TxRepositories) error) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	repos := TxRepositories{
+		Repositories: Repositories{
+			Sessions:    &SQLSessionRepository{db: tx, useDollar: t.useDollar},
+			Subjects:    &SQLSubjectRepository{db: tx, useDollar: t.useDollar, logger: t.logger},
+			Revisions:   &SQLRevisionRepository{db: tx, useDollar: t.useDollar},
+			Tags:        &SQLTagRepository{db: tx, useDollar: t.useDollar},
+			Pomodoro:    &SQLPomodoroSettingsRepository{db: tx, useDollar: t.useDollar},
+			Preferences: &SQLPreferencesRepository{db: tx, useDollar: t.useDollar},
+		},
+		Savepoint: func(ctx context.Context, fn func() error) error {
+			return savepoint(ctx, tx, fn)
+		},
+	}
+
+	if err := fn(repos); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// savepoint is supported by both SQLite and Postgres. Savepoints nest, so
+// reusing one name is fine.
+func savepoint(ctx context.Context, tx *sql.Tx, fn func() error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT study_item"); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT study_item"); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		// ROLLBACK TO keeps the savepoint open; release it to unwind the stack.
+		if _, relErr := tx.ExecContext(ctx, "RELEASE SAVEPOINT study_item"); relErr != nil {
+			return errors.Join(err, relErr)
+		}
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT study_item")
+	return err
+}
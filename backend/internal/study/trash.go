@@ -0,0 +1,97 @@
+package study
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Trash lists deleted items that can still be restored.
+type Trash struct {
+	Sessions []StudySession `json:"sessions"`
+	Subjects []Subject      `json:"subjects"`
+	// PurgeAfter is how long items stay in the trash, e.g. "720h0m0s".
+	PurgeAfter string `json:"purgeAfter"`
+}
+
+// ListTrash returns the user's deleted sessions and subjects.
+func (s *Service) ListTrash(ctx context.Context, userID string) (Trash, error) {
+	trash := Trash{Sessions: []StudySession{}, Subjects: []Subject{}, PurgeAfter: s.trashRetention.String()}
+
+	sessions, err := s.sessions.ListDeleted(userID)
+	if err != nil {
+		return Trash{}, err
+	}
+	trash.Sessions = append(trash.Sessions, sessions...)
+
+	if s.subjects != nil {
+		subjects, err := s.subjects.ListDeleted(userID)
+		if err != nil {
+			return Trash{}, err
+		}
+		trash.Subjects = append(trash.Subjects, subjects...)
+	}
+	return trash, nil
+}
+
+// RestoreSession brings a deleted session back. If its subject was deleted
+// too, the subject is restored with it.
+func (s *Service) RestoreSession(ctx context.Context, userID, id string, version int) (StudySession, error) {
+	var restored StudySession
+	err := s.atomically(ctx, func(scoped *Service) error {
+		if err := scoped.sessions.Restore(userID, id, version); err != nil {
+			return err
+		}
+		session, err := scoped.sessions.Get(userID, id)
+		if err != nil {
+			return err
+		}
+		if session.SubjectID != "" && scoped.subjects != nil {
+			err := scoped.subjects.Restore(userID, session.SubjectID, 0)
+			if err != nil && !errors.Is(err, ErrSubjectNotFound) {
+				return err
+			}
+		}
+		restored = session
+		return scoped.recordRevision(userID, RevisionRestore, nil, &restored)
+	})
+	if err != nil {
+		return StudySession{}, err
+	}
+	s.logger.InfoContext(ctx, "study session restored", "userId", userID, "sessionId", id)
+	return restored, nil
+}
+
+// RestoreSubject brings a deleted subject back, relinking the sessions that
+// still reference it.
+func (s *Service) RestoreSubject(ctx context.Context, userID, id string, version int) (Subject, error) {
+	if s.subjects == nil {
+		return Subject{}, errors.New("subject repository not configured")
+	}
+	if err := s.subjects.Restore(userID, id, version); err != nil {
+		return Subject{}, err
+	}
+	subject, err := s.subjects.Get(userID, id)
+	if err != nil {
+		return Subject{}, err
+	}
+	s.logger.InfoContext(ctx, "subject restored", "userId", userID, "subjectId", id)
+	return subject, nil
+}
+
+// PurgeTrash permanently removes items deleted longer ago than the
+// retention period. Sessions go first so their subjects can follow.
+func (s *Service) PurgeTrash(ctx context.Context, now time.Time) (sessions, subjects int64, err error) {
+	cutoff := now.Add(-s.trashRetention)
+	sessions, err = s.sessions.PurgeDeleted(cutoff)
+	if err != nil {
+		return 0, 0, err
+	}
+	if s.subjects != nil {
+		subjects, err = s.subjects.PurgeDeleted(cutoff)
+		if err != nil {
+			return sessions, 0, err
+		}
+	}
+	return sessions, subjects, nil
+}
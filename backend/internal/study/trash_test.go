@@ -0,0 +1,118 @@
+package study
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// trashSubjectOf moves the subject of session to the trash.
+func trashSubjectOf(t *testing.T, svc *Service, session StudySession) {
+	t.Helper()
+	if err := svc.DeleteSubject(context.Background(), testUser, session.SubjectID, 0); err != nil {
+		t.Fatalf("delete subject: %v", err)
+	}
+}
+
+func TestSessionsOfTrashedSubjectStayEditable(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(svc *Service, first, second StudySession) ([]StudySession, error)
+	}{
+		{
+			name: "patch",
+			edit: func(svc *Service, first, _ StudySession) ([]StudySession, error) {
+				patched, err := svc.PatchSession(context.Background(), testUser, first.ID, []byte(`{"notes":"reviewed"}`), 0)
+				return []StudySession{patched}, err
+			},
+		},
+		{
+			name: "put",
+			edit: func(svc *Service, first, _ StudySession) ([]StudySession, error) {
+				first.Notes = "reviewed"
+				updated, err := svc.UpdateSession(context.Background(), testUser, first)
+				return []StudySession{updated}, err
+			},
+		},
+		{
+			name: "split",
+			edit: func(svc *Service, first, _ StudySession) ([]StudySession, error) {
+				return svc.SplitSession(context.Background(), testUser, first.ID, SplitRequest{At: at(8, 30)}, 0)
+			},
+		},
+		{
+			name: "merge",
+			edit: func(svc *Service, first, second StudySession) ([]StudySession, error) {
+				merged, err := svc.MergeSessions(context.Background(), testUser, MergeRequest{SessionIDs: []string{first.ID, second.ID}})
+				return []StudySession{merged}, err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			first := mustCreateSession(t, svc, "Physics", at(8, 0), at(9, 0))
+			second := mustCreateSession(t, svc, "Physics", at(9, 0), at(10, 0))
+			trashSubjectOf(t, svc, first)
+
+			sessions, err := tt.edit(svc, first, second)
+			if err != nil {
+				t.Fatalf("edit: %v", err)
+			}
+			for _, session := range sessions {
+				if session.SubjectID != first.SubjectID || session.Subject != "Physics" {
+					t.Fatalf("session moved to subject %s (%s), want it kept on %s", session.SubjectID, session.Subject, first.SubjectID)
+				}
+			}
+		})
+	}
+}
+
+func TestTrashedSubjectBlocksNewSessions(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	existing := mustCreateSession(t, svc, "Physics", at(8, 0), at(9, 0))
+	trashSubjectOf(t, svc, existing)
+
+	if _, err := svc.CreateSession(ctx, testUser, StudySession{Subject: "Physics", StartTime: at(10, 0), EndTime: at(11, 0)}); !errors.Is(err, ErrSubjectInTrash) {
+		t.Fatalf("create: err = %v, want ErrSubjectInTrash", err)
+	}
+	// Moving an existing session onto the trashed subject is a new link too.
+	other := mustCreateSession(t, svc, "Math", at(10, 0), at(11, 0))
+	if _, err := svc.PatchSession(ctx, testUser, other.ID, []byte(`{"subject":"Physics"}`), 0); !errors.Is(err, ErrSubjectInTrash) {
+		t.Fatalf("patch: err = %v, want ErrSubjectInTrash", err)
+	}
+}
+
+func TestRestoreSessionRestoresItsSubject(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	session := mustCreateSession(t, svc, "Physics", at(8, 0), at(9, 0))
+	trashSubjectOf(t, svc, session)
+	if err := svc.DeleteSession(ctx, testUser, session.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	trash, err := svc.ListTrash(ctx, testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash.Sessions) != 1 || len(trash.Subjects) != 1 {
+		t.Fatalf("trash = %d sessions, %d subjects; want 1 and 1", len(trash.Sessions), len(trash.Subjects))
+	}
+
+	restored, err := svc.RestoreSession(ctx, testUser, session.ID, 0)
+	if err != nil {
+		t.Fatalf("RestoreSession: %v", err)
+	}
+	if restored.SubjectID != session.SubjectID {
+		t.Fatalf("restored subjectId = %s, want %s", restored.SubjectID, session.SubjectID)
+	}
+	subjects, err := svc.ListSubjects(ctx, testUser, SubjectFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subjects) != 1 || subjects[0].ID != session.SubjectID {
+		t.Fatalf("live subjects = %+v, want the restored subject", subjects)
+	}
+}
@@ -95,6 +95,8 @@ if (typeof Chart !== "undefined") {
 }
 
 let sessions = [];
+// Token from the last /sync response; null forces a full reload.
+let sessionSyncToken = null;
 let subjects = [];
 let summaryData = null;
 let editingSessionId = null;
@@ -178,6 +180,19 @@ function escapeHTML(value) {
     : "";
 }
 
+// versionHeaders returns an If-Match header for the loaded copy of a resource
+// so edits made elsewhere in the meantime are rejected instead of overwritten.
+function versionHeaders(items, id) {
+  const item = items.find((entry) => entry.id === id);
+  return item && item.version ? { "If-Match": `"${item.version}"` } : {};
+}
+
+function conflictMessage(error, fallback) {
+  return error.code === "version_conflict"
+    ? "This item was changed elsewhere. Reload and try again."
+    : fallback;
+}
+
 async function fetchJSON(url, options = {}) {
   const mergedOptions = {
     credentials: "include",
@@ -195,8 +210,20 @@ async function fetchJSON(url, options = {}) {
 
   const response = await fetch(url, mergedOptions);
   if (!response.ok) {
-    const message = await response.text();
-    throw new Error(message || "Request failed");
+    // The API answers errors with {code, message, details, requestId}.
+    const text = await response.text();
+    let body = null;
+    try {
+      body = text ? JSON.parse(text) : null;
+    } catch (_) {
+      body = null;
+    }
+    const error = new Error((body && body.message) || text || "Request failed");
+    error.status = response.status;
+    error.code = body && body.code;
+    error.details = (body && body.details) || [];
+    error.requestId = body && body.requestId;
+    throw error;
   }
   if (response.status === 204) {
     return null;
@@ -625,7 +652,7 @@ async function handleLiveTrackLog() {
       startTime: startTime.toISOString(),
       endTime: endTime.toISOString(),
     };
-    await fetchJSON("/api/study-sessions", {
+    await fetchJSON("/api/v1/study-sessions", {
       method: "POST",
       headers: { "Content-Type": "application/json" },
       body: JSON.stringify(payload),
@@ -709,7 +736,7 @@ resetLiveTrackState();
 
 async function loadSubjects() {
   try {
-    const result = await fetchJSON("/api/subjects");
+    const result = await fetchJSON("/api/v1/subjects");
     subjects = Array.isArray(result) ? result : [];
     renderSubjects();
     syncSubjectOptions();
@@ -722,9 +749,42 @@ async function loadSubjects() {
   }
 }
 
+// mergeSessionChanges applies a /sync delta to the local session list. Items
+// can repeat across syncs, so the copy with the higher version wins.
+function mergeSessionChanges(changes) {
+  const byId = new Map(
+    (changes.full ? [] : sessions).map((session) => [session.id, session])
+  );
+  for (const session of changes.sessions || []) {
+    const known = byId.get(session.id);
+    if (!known || (known.version || 0) <= (session.version || 0)) {
+      byId.set(session.id, session);
+    }
+  }
+  for (const tombstone of changes.deletedSessions || []) {
+    const known = byId.get(tombstone.id);
+    if (known && (known.version || 0) <= tombstone.version) {
+      byId.delete(tombstone.id);
+    }
+  }
+  sessions = Array.from(byId.values()).sort(
+    (a, b) => new Date(b.startTime) - new Date(a.startTime)
+  );
+}
+
 async function loadSessions() {
   try {
-    sessions = await fetchJSON("/api/study-sessions");
+    const query = sessionSyncToken ? `?since=${encodeURIComponent(sessionSyncToken)}` : "";
+    let changes;
+    try {
+      changes = await fetchJSON(`/api/v1/sync${query}`);
+    } catch (error) {
+      if (error.code !== "invalid_sync_token" && error.code !== "sync_token_expired") throw error;
+      sessionSyncToken = null;
+      changes = await fetchJSON("/api/v1/sync");
+    }
+    mergeSessionChanges(changes);
+    sessionSyncToken = changes.token;
     renderSessions();
     renderHistory();
     syncSubjectOptions();
@@ -738,7 +798,7 @@ async function loadSessions() {
 
 async function loadSummary() {
   try {
-    summaryData = await fetchJSON("/api/progress/summary");
+    summaryData = await fetchJSON("/api/v1/progress/summary");
     renderSummary();
   } catch (error) {
     console.error("Failed to load summary", error);
@@ -898,9 +958,26 @@ function renderSummary(summary = summaryData) {
   streakDaysEl.textContent = summary.streakDays ?? 0;
   renderStreakChip(summary.streakDays ?? 0);
 
-  renderSubjectBreakdown(summary.bySubject || {});
-  updateSubjectChart(summary.bySubject || {});
-  updateTrendChart(summary.dailyTrend || []);
+  const names = summary.subjectNames || {};
+  const bySubject = subjectMinutesByName(summary.bySubject, names);
+  renderSubjectBreakdown(bySubject);
+  updateSubjectChart(bySubject);
+  updateTrendChart(
+    (summary.dailyTrend || []).map((entry) => ({
+      ...entry,
+      bySubject: subjectMinutesByName(entry.bySubject, names),
+    }))
+  );
+}
+
+// The summary keys minutes by subject ID; charts label them by name.
+function subjectMinutesByName(byId, names) {
+  const byName = {};
+  Object.entries(byId || {}).forEach(([id, minutes]) => {
+    const name = names[id] || id;
+    byName[name] = (byName[name] || 0) + minutes;
+  });
+  return byName;
 }
 
 function renderStreakChip(streakDays) {
@@ -1364,6 +1441,8 @@ function setAuthenticated(user) {
 
   if (!isAuthenticated) {
     dataLoaded = false;
+    sessions = [];
+    sessionSyncToken = null;
     resetLiveTrackState();
     setAuthMode("login");
     activateView("auth", { skipSave: true, force: true });
@@ -1382,7 +1461,7 @@ function setAuthMode(mode) {
 
 async function loadCurrentUser() {
   try {
-    const user = await fetchJSON("/api/auth/me", { headers: {} });
+    const user = await fetchJSON("/api/v1/auth/me", { headers: {} });
     setAuthenticated(user);
   } catch (error) {
     setAuthenticated(null);
@@ -1534,15 +1613,18 @@ async function handleSessionSubmit(event) {
   };
 
   const url = editingSessionId
-    ? `/api/study-sessions/${editingSessionId}`
-    : "/api/study-sessions";
+    ? `/api/v1/study-sessions/${editingSessionId}`
+    : "/api/v1/study-sessions";
 
   const method = editingSessionId ? "PUT" : "POST";
 
   try {
     await fetchJSON(url, {
       method,
-      headers: { "Content-Type": "application/json" },
+      headers: {
+        "Content-Type": "application/json",
+        ...(editingSessionId ? versionHeaders(sessions, editingSessionId) : {}),
+      },
       body: JSON.stringify(payload),
     });
 
@@ -1550,7 +1632,7 @@ async function handleSessionSubmit(event) {
     await Promise.all([loadSessions(), loadSummary(), loadSubjects()]);
   } catch (error) {
     console.error("Failed to submit session", error);
-    showMessage(sessionErrorEl, error.message);
+    showMessage(sessionErrorEl, conflictMessage(error, error.message));
   }
 }
 
@@ -1567,11 +1649,11 @@ async function handleSubjectSubmit(event) {
   }
 
   const payload = { name, color };
-  let url = "/api/subjects";
+  let url = "/api/v1/subjects";
   let method = "POST";
 
   if (editingSubjectId) {
-    url = `/api/subjects/${editingSubjectId}`;
+    url = `/api/v1/subjects/${editingSubjectId}`;
     method = "PUT";
     payload.id = editingSubjectId;
   }
@@ -1579,7 +1661,10 @@ async function handleSubjectSubmit(event) {
   try {
     await fetchJSON(url, {
       method,
-      headers: { "Content-Type": "application/json" },
+      headers: {
+        "Content-Type": "application/json",
+        ...(editingSubjectId ? versionHeaders(subjects, editingSubjectId) : {}),
+      },
       body: JSON.stringify(payload),
     });
 
@@ -1587,7 +1672,7 @@ async function handleSubjectSubmit(event) {
     await loadSubjects();
   } catch (error) {
     console.error("Failed to submit subject", error);
-    showMessage(subjectErrorEl, error.message);
+    showMessage(subjectErrorEl, conflictMessage(error, error.message));
   }
 }
 
@@ -1607,15 +1692,14 @@ async function ensureSubjectExists(name, color) {
   }
 
   try {
-    await fetchJSON("/api/subjects", {
+    await fetchJSON("/api/v1/subjects", {
       method: "POST",
       headers: { "Content-Type": "application/json" },
       body: JSON.stringify({ name, color }),
     });
     await loadSubjects();
   } catch (error) {
-    const message = (error.message || "").toLowerCase();
-    if (message.includes("exists")) {
+    if (error.code === "subject_name_exists") {
       await loadSubjects();
     } else {
       throw error;
@@ -1734,9 +1818,9 @@ async function saveInlineSubjectEdit(id) {
   }
 
   try {
-    await fetchJSON(`/api/subjects/${id}`, {
+    await fetchJSON(`/api/v1/subjects/${id}`, {
       method: "PUT",
-      headers: { "Content-Type": "application/json" },
+      headers: { "Content-Type": "application/json", ...versionHeaders(subjects, id) },
       body: JSON.stringify({ id, name, color }),
     });
     inlineSubjectEditId = null;
@@ -1745,18 +1829,21 @@ async function saveInlineSubjectEdit(id) {
     showMessage(subjectErrorEl, "");
   } catch (error) {
     console.error("Failed to update subject", error);
-    showMessage(subjectErrorEl, error.message || "Failed to update subject.");
+    showMessage(subjectErrorEl, conflictMessage(error, error.message || "Failed to update subject."));
   }
 }
 
 async function deleteSession(id) {
   if (!window.confirm("Delete this study session?")) return;
   try {
-    await fetchJSON(`/api/study-sessions/${id}`, { method: "DELETE" });
+    await fetchJSON(`/api/v1/study-sessions/${id}`, {
+      method: "DELETE",
+      headers: versionHeaders(sessions, id),
+    });
     await Promise.all([loadSessions(), loadSummary(), loadSubjects()]);
   } catch (error) {
     console.error("Failed to delete session", error);
-    showMessage(sessionErrorEl, "Failed to delete session.");
+    showMessage(sessionErrorEl, conflictMessage(error, "Failed to delete session."));
   }
 }
 
@@ -1769,14 +1856,17 @@ async function deleteSubject(id) {
     return;
   }
   try {
-    await fetchJSON(`/api/subjects/${id}`, { method: "DELETE" });
+    await fetchJSON(`/api/v1/subjects/${id}`, {
+      method: "DELETE",
+      headers: versionHeaders(subjects, id),
+    });
     if (editingSubjectId === id) {
       resetSubjectForm();
     }
     await loadSubjects();
   } catch (error) {
     console.error("Failed to delete subject", error);
-    showMessage(subjectErrorEl, "Failed to delete subject.");
+    showMessage(subjectErrorEl, conflictMessage(error, "Failed to delete subject."));
   }
 }
 
@@ -2027,7 +2117,7 @@ if (loginForm) {
     const password = document.getElementById("login-password").value;
     showMessage(loginErrorEl, "");
     try {
-      await fetchJSON("/api/auth/login", {
+      await fetchJSON("/api/v1/auth/login", {
         method: "POST",
         body: JSON.stringify({ email, password }),
       });
@@ -2048,7 +2138,7 @@ if (registerForm) {
     const password = document.getElementById("register-password").value;
     showMessage(registerErrorEl, "");
     try {
-      await fetchJSON("/api/auth/register", {
+      await fetchJSON("/api/v1/auth/register", {
         method: "POST",
         body: JSON.stringify({ email, password }),
       });
@@ -2065,7 +2155,7 @@ if (registerForm) {
 if (googleLoginBtn) {
   googleLoginBtn.addEventListener("click", async () => {
     try {
-      const result = await fetchJSON("/api/auth/google/login", { headers: {} });
+      const result = await fetchJSON("/api/v1/auth/google/login", { headers: {} });
       if (result?.url) {
         window.location.href = result.url;
       }
@@ -2078,7 +2168,7 @@ if (googleLoginBtn) {
 if (logoutBtn) {
   logoutBtn.addEventListener("click", async () => {
     try {
-      await fetchJSON("/api/auth/logout", { method: "POST", headers: {} });
+      await fetchJSON("/api/v1/auth/logout", { method: "POST", headers: {} });
     } finally {
       setAuthenticated(null);
     }